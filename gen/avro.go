@@ -0,0 +1,228 @@
+package gen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// avroSchema is an Avro complex type (record, enum, array, map, or fixed).
+type avroSchema struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace"`
+	Doc       string      `json:"doc"`
+	Fields    []avroField `json:"fields"`
+	Symbols   []string    `json:"symbols"`
+	Items     interface{} `json:"items"`
+	Values    interface{} `json:"values"`
+}
+
+type avroField struct {
+	Name    string      `json:"name"`
+	Type    interface{} `json:"type"`
+	Doc     string      `json:"doc"`
+	Default interface{} `json:"default"`
+}
+
+const (
+	avroNull    = "null"
+	avroBoolean = "boolean"
+	avroInt     = "int"
+	avroLong    = "long"
+	avroFloat   = "float"
+	avroDouble  = "double"
+	avroBytes   = "bytes"
+	avroString  = "string"
+	avroRecord  = "record"
+	avroEnum    = "enum"
+	avroArray   = "array"
+	avroMap     = "map"
+	avroFixed   = "fixed"
+)
+
+var avroPrimitives = map[string]string{
+	avroNull:    typeEmptyInterface,
+	avroBoolean: typeBool,
+	avroInt:     typeInt,
+	avroLong:    typeInt64,
+	avroFloat:   typeFloat64,
+	avroDouble:  typeFloat64,
+	avroBytes:   "[]byte",
+	avroString:  typeString,
+}
+
+func parseAvro(data []byte) (interface{}, error) {
+	var s interface{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func getAvroSchema(typeInterface interface{}) (*avroSchema, error) {
+	typeSchemaJSON, _ := json.Marshal(typeInterface)
+	var typeSchema avroSchema
+	if err := json.Unmarshal(typeSchemaJSON, &typeSchema); err != nil {
+		return nil, err
+	}
+	return &typeSchema, nil
+}
+
+func avroFullName(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// processAvroType converts the Avro type t to the prefix and type reference used by goType and structField. Named
+// types (records, enums, and fixed) are added to types. nullable is true for a union of null and a single other type.
+func (g *generator) processAvroType(t interface{}, namespace string) (typePrefix, typeRef string, nullable bool, err error) {
+	switch t := t.(type) {
+	case string:
+		if ts, ok := avroPrimitives[t]; ok {
+			return ts, "", false, nil
+		}
+		if ref, ok := g.avroNames[avroFullName(t, namespace)]; ok {
+			return "", ref, false, nil
+		}
+		if ref, ok := g.avroNames[t]; ok {
+			return "", ref, false, nil
+		}
+		return "", "", false, fmt.Errorf("unknown Avro type %q", t)
+	case []interface{}:
+		var nonNull []interface{}
+		for _, member := range t {
+			if member != avroNull {
+				nonNull = append(nonNull, member)
+			}
+		}
+		if len(nonNull) != 1 {
+			return typeEmptyInterface, "", false, nil
+		}
+		typePrefix, typeRef, _, err = g.processAvroType(nonNull[0], namespace)
+		if err != nil {
+			return
+		}
+		// slices and maps are already nilable
+		isContainer := strings.HasPrefix(typePrefix, "[]") || strings.HasPrefix(typePrefix, "map[")
+		return typePrefix, typeRef, len(t) > 1 && !isContainer, nil
+	case map[string]interface{}:
+		s, err := getAvroSchema(t)
+		if err != nil {
+			return "", "", false, err
+		}
+		return g.processAvroSchema(s, namespace)
+	default:
+		return "", "", false, fmt.Errorf("invalid Avro type %v", t)
+	}
+}
+
+func (g *generator) processAvroSchema(s *avroSchema, namespace string) (typePrefix, typeRef string, nullable bool, err error) {
+	switch s.Type {
+	case avroArray:
+		typePrefix, typeRef, _, err = g.processAvroType(s.Items, namespace)
+		return "[]" + typePrefix, typeRef, false, err
+	case avroMap:
+		typePrefix, typeRef, _, err = g.processAvroType(s.Values, namespace)
+		return "map[string]" + typePrefix, typeRef, false, err
+	case avroRecord, avroEnum, avroFixed:
+		typeRef, err = g.processAvroNamedType(s, namespace)
+		return "", typeRef, false, err
+	default:
+		// a primitive type in object form, e.g. {"type": "string"}
+		return g.processAvroType(s.Type, namespace)
+	}
+}
+
+func (g *generator) processAvroNamedType(s *avroSchema, namespace string) (typeRef string, err error) {
+	if s.Name == "" {
+		return "", errors.New("Avro " + s.Type + " without a name")
+	}
+	if s.Namespace != "" {
+		namespace = s.Namespace
+	}
+	fullName := avroFullName(s.Name, namespace)
+	typeRef = "avro:" + fullName
+	if _, ok := g.types[typeRef]; ok {
+		return "", fmt.Errorf("Avro type %q defined more than once", fullName)
+	}
+
+	shortName := fullName[strings.LastIndex(fullName, ".")+1:]
+	gt := goType{
+		Comment:      s.Doc,
+		origTypeName: shortName,
+	}
+	if len(g.avroNames) == 0 && g.RootTypeName != "" {
+		gt.Name = g.RootTypeName
+	} else {
+		gt.Name = g.generateTypeName(shortName)
+	}
+
+	// register the name before processing fields so records can refer to themselves
+	g.avroNames[fullName] = typeRef
+	g.avroNames[shortName] = typeRef
+	g.types[typeRef] = gt
+	g.typesByName.addTo(gt.Name, typeRef)
+
+	switch s.Type {
+	case avroEnum:
+		gt.TypePrefix = typeString
+		for _, symbol := range s.Symbols {
+			gt.Enum = append(gt.Enum, symbol)
+		}
+	case avroFixed:
+		gt.TypePrefix = typeString
+	case avroRecord:
+		gt.TypePrefix = typeStruct
+		for _, field := range s.Fields {
+			sf := structField{
+				PropertyName: field.Name,
+				Order:        len(gt.Fields),
+			}
+			if sf.Name = g.generateFieldName(field.Name); sf.Name == "" {
+				return "", fmt.Errorf("can't generate field name for %q", field.Name)
+			}
+			sf.TypePrefix, sf.TypeRef, sf.Nullable, err = g.processAvroType(field.Type, namespace)
+			if err != nil {
+				return "", fmt.Errorf("field %q of %s: %s", field.Name, fullName, err)
+			}
+			if sf.TypeRef == typeRef && sf.TypePrefix == "" {
+				// a record can only contain itself through a pointer
+				sf.Nullable = true
+			}
+			sf.Required = !sf.Nullable && field.Default == nil
+			gt.Fields = append(gt.Fields, sf)
+		}
+		gt.Fields = g.dedupeFieldNames(gt.Fields)
+	}
+	g.types[typeRef] = gt
+
+	return typeRef, nil
+}
+
+// processAvro adds Go types for the parsed Avro schema s. Named types take their names from the schema; the first
+// named type is the root type and uses RootTypeName if given.
+func (g *generator) processAvro(s interface{}) error {
+	typePrefix, typeRef, nullable, err := g.processAvroType(s, "")
+	if err != nil {
+		return err
+	}
+	rootPath := typeRef
+	if typeRef == "" || typePrefix != "" || nullable {
+		// the top level isn't a named type, so give it one
+		g.nameRoot("")
+		rootPath = "#"
+		g.types[rootPath] = goType{Name: g.RootTypeName, TypePrefix: typePrefix, TypeRef: typeRef}
+		g.typesByName.addTo(g.RootTypeName, rootPath)
+	} else if g.RootTypeName == "" {
+		g.RootTypeName = g.types[typeRef].Name
+	}
+	g.dedupeTypes()
+	g.prune(rootPath)
+	g.breakCycles()
+
+	return nil
+}
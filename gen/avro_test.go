@@ -0,0 +1,81 @@
+package gen
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// generateFromAvroString runs the generator over the Avro schema avroJSON with the current options.
+func generateFromAvroString(avroJSON string) (string, error) {
+	avroOpts := opts
+	avroOpts.Avro = true
+	src, err := Generate([]byte(avroJSON), avroOpts)
+	return string(src), err
+}
+
+func TestAvro(t *testing.T) {
+	Convey("Given an Avro record with a nullable union field and an enum", t, func() {
+		resetGenerator()
+		opts.RootTypeName = ""
+		schema := `{
+			"type": "record",
+			"name": "Card",
+			"namespace": "com.example",
+			"doc": "A playing card.",
+			"fields": [
+				{"name": "suit", "type": {"type": "enum", "name": "Suit", "symbols": ["HEARTS", "SPADES"]}},
+				{"name": "rank", "type": "int"},
+				{"name": "serial", "type": "long"},
+				{"name": "image", "type": "bytes"},
+				{"name": "nickname", "type": ["null", "string"], "default": null},
+				{"name": "previous_suit", "type": ["null", "Suit"]},
+				{"name": "tags", "type": {"type": "array", "items": "string"}},
+				{"name": "next", "type": ["null", "Card"]}
+			]
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromAvroString(schema)
+
+			Convey("Then the record should be a struct named after the record", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "// A playing card.\ntype card struct {")
+				So(compact(src), ShouldContainSubstring, "Rank int `json:\"rank\"`")
+				So(compact(src), ShouldContainSubstring, "Serial int64 `json:\"serial\"`")
+				So(compact(src), ShouldContainSubstring, "Image []byte `json:\"image\"`")
+				So(compact(src), ShouldContainSubstring, "Tags []string `json:\"tags\"`")
+			})
+
+			Convey("Then nullable unions should be pointers", func() {
+				So(compact(src), ShouldContainSubstring, "Nickname *string `json:\"nickname,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "PreviousSuit *suit `json:\"previous_suit,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "Next *card `json:\"next,omitempty\"`")
+			})
+
+			Convey("Then the enum should be a string type with constants", func() {
+				So(compact(src), ShouldContainSubstring, "Suit suit `json:\"suit\"`")
+				So(compact(src), ShouldContainSubstring, "type suit string")
+				So(compact(src), ShouldContainSubstring, `suitHearts suit = "HEARTS"`)
+				So(compact(src), ShouldContainSubstring, `suitSpades suit = "SPADES"`)
+			})
+
+			Convey("Then the output should compile", func() {
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given an Avro record referencing an undefined type", t, func() {
+		resetGenerator()
+		schema := `{"type": "record", "name": "Thing", "fields": [{"name": "other", "type": "Other"}]}`
+
+		Convey("When we generate", func() {
+			_, err := generateFromAvroString(schema)
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,218 @@
+package gen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// schemaLoader loads external schema documents, reading and parsing each document at most once per run.
+type schemaLoader struct {
+	read func(uri string) ([]byte, error)
+	docs map[string]*metaSchema
+}
+
+func newSchemaLoader(read func(uri string) ([]byte, error)) *schemaLoader {
+	return &schemaLoader{read: read, docs: make(map[string]*metaSchema)}
+}
+
+// load returns the parsed document at uri, which should already be resolved (see resolveDocURI) so that different
+// references to the same document share a cache entry. The references in the document are resolved against uri.
+func (l *schemaLoader) load(uri string) (*metaSchema, error) {
+	if doc, ok := l.docs[uri]; ok {
+		return doc, nil
+	}
+
+	data, err := l.read(uri)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseSchema(data, filepath.Dir(uri), uri)
+	if err != nil {
+		return nil, err
+	}
+	l.docs[uri] = doc
+	return doc, nil
+}
+
+// parseSchema parses the schema document in data, recording the order of properties and resolving references with
+// resolveRefs. The boolean schema true allows any value, so it is parsed as the empty schema; false allows none, so
+// it has no type and is an error.
+func parseSchema(data []byte, dir, docURI string) (*metaSchema, error) {
+	raw, err := decodeSchemaJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if allowed, ok := raw.(bool); ok {
+		if !allowed {
+			return nil, errors.New("the schema is false, which no value is valid against, so it has no type")
+		}
+		return &metaSchema{}, nil
+	}
+	resolveRefs(raw, dir, docURI)
+	resolved, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var doc metaSchema
+	if err = json.Unmarshal(resolved, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// resolveRefs rewrites the $ref values in the parsed JSON v so that they identify the same schema from any document:
+// references to other documents are resolved against dir, the directory of the document containing them, and local
+// references in an external document are prefixed with its URI, docURI. Local references in the root document, whose
+// docURI is empty, are left as they are.
+func resolveRefs(v interface{}, dir, docURI string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if ref, ok := val.(string); ok && key == "$ref" {
+				v[key] = resolveRef(ref, dir, docURI)
+				continue
+			}
+			resolveRefs(val, dir, docURI)
+		}
+	case []interface{}:
+		for _, val := range v {
+			resolveRefs(val, dir, docURI)
+		}
+	}
+}
+
+func resolveRef(ref, dir, docURI string) string {
+	docRef, fragment := splitRef(ref)
+	if docRef == "" {
+		if docURI == "" {
+			return ref
+		}
+		return docURI + "#" + fragment
+	}
+	return resolveDocPath(dir, docRef) + "#" + fragment
+}
+
+// splitRef splits a reference into the document it refers to, which is empty for a local reference, and the JSON
+// pointer within it.
+func splitRef(ref string) (docRef, fragment string) {
+	if hash := strings.Index(ref, "#"); hash >= 0 {
+		return ref[:hash], ref[hash+1:]
+	}
+	return ref, ""
+}
+
+// resolveDocURI resolves the document part of a reference relative to the document containing it.
+func resolveDocURI(baseURI, docRef string) string {
+	return resolveDocPath(filepath.Dir(baseURI), docRef)
+}
+
+// resolveDocPath resolves the document part of a reference relative to dir.
+func resolveDocPath(dir, docRef string) string {
+	if filepath.IsAbs(docRef) {
+		return filepath.Clean(docRef)
+	}
+	return filepath.Join(dir, docRef)
+}
+
+// schemaAt returns the schema at the JSON pointer within doc, along with a name for it, which is the last token of the
+// pointer or, for the whole document, the name of its file.
+func schemaAt(doc *metaSchema, docURI, pointer string) (*metaSchema, string, error) {
+	name := strings.TrimSuffix(filepath.Base(docURI), filepath.Ext(docURI))
+	if pointer == "" || pointer == "/" {
+		return doc, name, nil
+	}
+
+	docJSON, _ := json.Marshal(doc)
+	var v interface{}
+	json.Unmarshal(docJSON, &v)
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch parent := v.(type) {
+		case map[string]interface{}:
+			v = parent[token]
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(parent) {
+				return nil, "", fmt.Errorf("no schema at %s#%s", docURI, pointer)
+			}
+			v = parent[index]
+		default:
+			v = nil
+		}
+		if v == nil {
+			return nil, "", fmt.Errorf("no schema at %s#%s", docURI, pointer)
+		}
+		name = token
+	}
+	return getTypeSchema(v), name, nil
+}
+
+// processLocalRef processes the type that ref refers to if it is a subschema of the root document that hasn't been
+// processed yet, such as #/properties/address, so that references to any location in the document resolve, not just
+// to definitions. The type is named after the property at the location, singularized for array items and values of
+// maps, as it would be if it were processed where it is.
+func (g *generator) processLocalRef(ref, parentPath string) {
+	docURI, pointer := splitRef(ref)
+	if docURI != "" || pointer == "" || pointer == "/" || g.rootSchema == nil || g.localRefs.Has(ref) {
+		return
+	}
+	if _, ok := g.types[ref]; ok {
+		return
+	}
+	if _, ok := g.deferredTypes[ref]; ok {
+		return
+	}
+	if tokens := strings.Split(pointer, "/"); len(tokens) > 2 && (tokens[len(tokens)-2] == "definitions" || tokens[len(tokens)-2] == "$defs") {
+		// definitions are all processed with the schema containing them
+		return
+	}
+	g.localRefs.Add(ref)
+
+	s, _, err := schemaAt(g.rootSchema, "", pointer)
+	if err != nil {
+		g.fail("can't resolve %s: %s", ref, err)
+	}
+	var name string
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "properties", "patternProperties", "definitions", "$defs":
+			if i+1 < len(tokens) {
+				i++
+				name = strings.Replace(strings.Replace(tokens[i], "~1", "/", -1), "~0", "~", -1)
+			}
+		case "items", "prefixItems", "additionalProperties":
+			name = singularize(name)
+		}
+	}
+	if g.processType(s, name, s.Description, ref, parentPath) == "" {
+		g.deferredTypes[ref] = deferredType{schema: s, name: name, desc: s.Description, parentPath: parentPath}
+	}
+}
+
+// processExternalRef processes the type that ref refers to if it is in another document, so that it can be resolved
+// like a local reference. parentPath is the path of the type containing the reference, which disambiguates the
+// type's name if needed.
+func (g *generator) processExternalRef(ref, parentPath string) {
+	docURI, pointer := splitRef(ref)
+	if docURI == "" || g.externalRefs.Has(ref) {
+		return
+	}
+	g.externalRefs.Add(ref)
+
+	doc, err := g.externalSchemas.load(docURI)
+	if err != nil {
+		g.fail("loading %s: %s", docURI, err)
+	}
+	s, name, err := schemaAt(doc, docURI, pointer)
+	if err != nil {
+		g.fail("%s", err)
+	}
+	if g.processType(s, name, s.Description, ref, parentPath) == "" {
+		g.deferredTypes[ref] = deferredType{schema: s, name: name, desc: s.Description, parentPath: parentPath}
+	}
+}
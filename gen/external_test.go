@@ -0,0 +1,87 @@
+package gen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSchemaLoader(t *testing.T) {
+	Convey("Given a loader with a counting reader", t, func() {
+		reads := make(map[string]int)
+		loader := newSchemaLoader(func(uri string) ([]byte, error) {
+			reads[uri]++
+			return []byte(`{"definitions": {"address": {"type": "string"}}}`), nil
+		})
+
+		Convey("When the same document is referenced from multiple places", func() {
+			for _, ref := range []string{"common.json", "./common.json", "../schemas/common.json"} {
+				_, err := loader.load(resolveDocURI("schemas/root.json", ref))
+				So(err, ShouldBeNil)
+			}
+
+			Convey("Then it should be read once", func() {
+				So(reads, ShouldResemble, map[string]int{"schemas/common.json": 1})
+			})
+		})
+
+		Convey("When a document is loaded", func() {
+			doc, err := loader.load("common.json")
+
+			Convey("Then it should be parsed", func() {
+				So(err, ShouldBeNil)
+				So(doc.Definitions, ShouldContainKey, "address")
+			})
+		})
+	})
+}
+
+func TestExternalRefs(t *testing.T) {
+	Convey("Given schema files that reference each other", t, func() {
+		resetGenerator()
+		dir, err := ioutil.TempDir("", "schematyper")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		files := map[string]string{
+			"common.json": `{"definitions": {
+				"address": {"type": "object", "properties": {"city": {"type": "string"}, "geo": {"$ref": "#/definitions/point"}}},
+				"point": {"type": "object", "properties": {"lat": {"type": "number"}}}
+			}}`,
+			"types/status.json": `{"type": "string", "enum": ["active"]}`,
+		}
+		for name, contents := range files {
+			So(os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644), ShouldBeNil)
+		}
+		opts.RefBaseDir = dir
+
+		Convey("When a schema references definitions and whole documents in them", func() {
+			src, err := generateFromString(`{"type": "object", "properties": {
+				"home": {"$ref": "common.json#/definitions/address"},
+				"work": {"$ref": "./common.json#/definitions/address"},
+				"status": {"$ref": "types/status.json"}
+			}}`)
+
+			Convey("Then the referenced types should be generated once", func() {
+				So(err, ShouldBeNil)
+				src = compact(src)
+				So(src, ShouldContainSubstring, "type schema struct {\n Home address `json:\"home,omitempty\"`\n Status status `json:\"status,omitempty\"`\n Work address `json:\"work,omitempty\"`\n}")
+				So(src, ShouldContainSubstring, "Geo point `json:\"geo,omitempty\"`")
+				So(src, ShouldContainSubstring, "type point struct")
+				So(src, ShouldContainSubstring, "statusActive status = \"active\"")
+			})
+		})
+
+		Convey("When a referenced file doesn't exist", func() {
+			_, err := generateFromString(`{"type": "object", "properties": {"home": {"$ref": "missing.json#/definitions/address"}}}`)
+
+			Convey("Then there should be an error naming it", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "missing.json")
+			})
+		})
+	})
+}
@@ -0,0 +1,553 @@
+// Package gen generates Go types from JSON schemas and Avro schemas. It is the library behind the schematyper command,
+// so it can be used from Go code and tests without running the command.
+package gen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/idubinskiy/schematyper/stringset"
+)
+
+// Options are the settings for Generate. The zero value generates unexported types in package main, like the command
+// does without flags.
+type Options struct {
+	// PackageName is the package of the generated file; default is "main". Types are exported unless it is "main".
+	PackageName string
+	// Unexported generates unexported types whatever the package, e.g. for an internal package. Fields stay exported so
+	// that they are marshalled.
+	Unexported bool
+	// DefinitionsPackage is the import path of a package, such as example.com/service/types, that GenerateFiles writes
+	// the types of definitions, and the types nested in them, to, in a directory named after the package. The other
+	// types refer to them by the package's name, and all types are exported so that they can. Definitions can't refer
+	// to the other types, since the packages would import each other. Generate doesn't support it.
+	DefinitionsPackage string
+	// RootTypeName is the name of the root type; default is generated from SchemaName. For Avro schemas, the default is
+	// the name of the first named type.
+	RootTypeName string
+	// SchemaName names the root type when RootTypeName isn't set, like the input's filename does for the command;
+	// default is the name given by the schema's $id (see IDName), or "schema".
+	SchemaName string
+	// TypeNamesPrefix is a prefix for non-root types.
+	TypeNamesPrefix string
+	// MaxNameLength is the length that the names of non-root types are abbreviated to if they are longer, keeping their
+	// start and ending with a hash of the whole name, so that different names stay different. It must be more than the
+	// length of the hash, 6. Default is 0, for no limit.
+	MaxNameLength int
+	// PtrForOmit uses a pointer to a struct for an object property that is represented as a struct if the property is
+	// not required.
+	PtrForOmit bool
+	// EmitPtrHelpers emits a generic Ptr function for constructing pointers to scalar values.
+	EmitPtrHelpers bool
+	// UseAny writes the empty interface as any, which requires Go 1.18, instead of interface{}.
+	UseAny bool
+	// Avro reads the schema as an Avro schema instead of a JSON schema.
+	Avro bool
+	// MapType is the type for objects with additionalProperties: MapTypeMap (the default) or MapTypeOrdered.
+	MapType string
+	// GenBuilders generates a builder type for each struct type.
+	GenBuilders bool
+	// Constructors generates a constructor for each struct type that sets the fields with a default in the schema to it.
+	Constructors bool
+	// MaxInlineDepth is how deeply objects can be nested in the root schema or a definition and still get a struct type
+	// of their own; deeper objects are map[string]interface{}. Default is 0, for no limit.
+	MaxInlineDepth int
+	// PruneTypes omits types that aren't referenced, directly or indirectly, by the root type or by KeepTypes.
+	PruneTypes bool
+	// KeepTypes are the names of types to keep, along with the types they reference, when pruning.
+	KeepTypes []string
+	// OnlyTypes are the names of the only types to generate, along with the types they reference, and those of
+	// KeepTypes. The root type is omitted unless it is named or referenced. Default is nil, for all types.
+	OnlyTypes []string
+	// EnumMarshalCheck generates a MarshalJSON method for enum types that rejects values that aren't constants.
+	EnumMarshalCheck bool
+	// EnumValidation generates an IsValid method for enum types, and a function returning all of their constants.
+	EnumValidation bool
+	// EnumStringer generates a String method for enum types that returns the name of a constant's value, from
+	// x-enum-varnames if the schema has it, so that integer enums are readable when formatted.
+	EnumStringer bool
+	// FieldSort is the order of struct fields: FieldSortName (the default), FieldSortRequiredFirst, or FieldSortSchema
+	// (or FieldSortSchemaOrder) for the order of the properties in the schema.
+	FieldSort string
+	// EnumErrors generates an Error method for string enum types that are named like errors or have x-go-error set.
+	EnumErrors bool
+	// UnexportPattern matches the property names that should be unexported fields.
+	UnexportPattern *regexp.Regexp
+	// AvoidBuiltinShadow adds a "Type" suffix to type names that are lowercase predeclared identifiers or keywords.
+	AvoidBuiltinShadow bool
+	// GenSQL generates Scan and Value methods for named scalar types.
+	GenSQL bool
+	// NDJSONDecoder generates a function that decodes newline-delimited JSON into a slice of records.
+	NDJSONDecoder bool
+	// RecursionStrategy is the type for fields that would make a struct contain itself: RecursionPointer (the
+	// default) or RecursionRawMessage.
+	RecursionStrategy string
+	// RedactPasswords uses a string type that hides its value when formatted for properties with format password.
+	RedactPasswords bool
+	// AllOfEmbed embeds the types of $ref members of allOf instead of copying their fields.
+	AllOfEmbed bool
+	// OneOfInterfaces generates oneOf schemas as interfaces with a marker method that their variants implement,
+	// instead of interface{}. Structs holding one get an UnmarshalJSON method that picks the variant if the oneOf has a
+	// discriminator; encoding/json can't unmarshal into the interface otherwise.
+	OneOfInterfaces bool
+	// NoOmitEmpty leaves omitempty out of the json tags of optional fields, so their zero values are marshalled.
+	NoOmitEmpty bool
+	// Pointers are the fields that are pointers: PointersNullable (the default) for nullable fields, PointersOptional
+	// for fields that are nullable or not required, or PointersNone.
+	Pointers string
+	// Tags are the libraries, such as json and yaml, that get a struct tag with the property name; default is json.
+	Tags []string
+	// JSONTagCase is the case of the property names in the struct tags: JSONTagCasePreserve (the default) keeps them as
+	// they are in the schema, and JSONTagCaseSnake and JSONTagCaseCamel convert them to snake_case or camelCase. Field
+	// names are unaffected.
+	JSONTagCase string
+	// NullMethods generates a MarshalJSON method for struct types with optional nullable fields that writes null for
+	// those that are nil, instead of leaving them out.
+	NullMethods bool
+	// StrictUnmarshal generates an UnmarshalJSON method that rejects unknown properties for struct types whose schemas
+	// have additionalProperties false.
+	StrictUnmarshal bool
+	// BSONTags adds bson tags for the MongoDB driver, with the property names lowercased, even if bson isn't in Tags.
+	BSONTags bool
+	// ValidateTags adds go-playground/validator validate tags for the minLength, maxLength, minimum, maximum, minItems
+	// and maxItems of properties, and a comment for their pattern.
+	ValidateTags bool
+	// UUIDType is the Go type for string properties with format uuid, including the path of the package that declares
+	// it, such as github.com/google/uuid.UUID; default is string. A type without a path is in the generated package.
+	UUIDType string
+	// TypeMappings maps formats to the Go types, with the paths of the packages that declare them as for UUIDType, for
+	// the scalar properties with those formats, e.g. {"email": "string", "decimal":
+	// "github.com/shopspring/decimal.Decimal"}. They take precedence over the types for built-in formats, including
+	// UUIDType, DateType and TimeType.
+	TypeMappings map[string]string
+	// AccessComments notes in their comments which fields are read-only or write-only.
+	AccessComments bool
+	// AccessTags adds an access tag, "read" or "write", to read-only and write-only fields.
+	AccessTags bool
+	// NumberType is the type for numbers: NumberTypeFloat64 (the default) or NumberTypeJSONNumber, which keeps their
+	// precision.
+	NumberType string
+	// DateType is the Go type for string properties with format date, including the path of the package that
+	// declares it as for UUIDType, such as cloud.google.com/go/civil.Date. Default is a generated date type, a
+	// time.Time that is marshalled as 2006-01-02, since time.Time only unmarshals full RFC 3339 timestamps.
+	DateType string
+	// TimeType is the Go type for string properties with format time, as for DateType. Default is a generated
+	// timeOfDay type, a time.Time that is marshalled as 15:04:05Z07:00.
+	TimeType string
+	// RefBaseDir is the directory that references to other schema files, such as "common.json#/definitions/address",
+	// are resolved against; default is the current directory.
+	RefBaseDir string
+	// Initialisms are words, such as SKU, that identifiers keep in uppercase in addition to the default ones, such as
+	// ID and URL; they match regardless of case.
+	Initialisms []string
+	// NoDefaultInitialisms leaves out the default initialisms, so only Initialisms are kept in uppercase.
+	NoDefaultInitialisms bool
+	// CommentWidth is the width, including the "// ", that type and field comments are wrapped at; default is 80. A
+	// negative width leaves comments unwrapped.
+	CommentWidth int
+	// NoComments leaves out the comments of types and fields, such as those from descriptions, so that changes to them
+	// don't change the generated source. The comments of generated methods and functions are kept.
+	NoComments bool
+	// NoBanner leaves out the line saying that the file is generated and by which command.
+	NoBanner bool
+	// FileComment is a comment, such as //nolint:all, written after the package clause of each generated file. Lines
+	// that aren't comments are made into comments.
+	FileComment string
+	// BuildTags is a build constraint expression, such as "generated", written as a //go:build line before the
+	// package clause of each generated file.
+	BuildTags string
+	// StrictKeywords fails on keywords that would change the generated types but aren't supported, such as if and
+	// not, instead of logging a warning for each of them.
+	StrictKeywords bool
+	// NoFormat returns the generated source as is instead of formatting it with gofmt, e.g. to see why it doesn't
+	// compile.
+	NoFormat bool
+	// Banner is the line saying that the file is generated, written as a comment before the package clause; default
+	// is "Code generated by <Command>. DO NOT EDIT.". Tools such as go vet and linters only treat the file as generated
+	// if it matches generatedPattern, so a warning is logged if it doesn't.
+	Banner string
+	// Command is named in the default Banner; default is "schematyper". It could be the command line, but that would
+	// make the output differ between machines.
+	Command string
+	// Summary, if set, receives an overview of the generated types, as printed by the command's --dry-run.
+	Summary io.Writer
+	// Stats, if set, receives the number of generated types, of types whose references were resolved after deferring
+	// them, and of types renamed because their names collided, as printed by the command's --summary.
+	Stats io.Writer
+}
+
+// generatedPattern matches the comment that marks a Go file as generated, as described in
+// https://go.dev/s/generatedcode.
+var generatedPattern = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// generator holds the settings and the types processed so far for a single call of Generate.
+type generator struct {
+	Options
+
+	types          map[string]goType
+	deferredTypes  map[string]deferredType
+	typesByName    stringSetMap
+	transitiveRefs map[string]string
+	// renamedTypes maps the paths of types renamed to disambiguate them to their original names.
+	renamedTypes map[string]string
+	// avroNames maps full and short Avro names to the path of the generated type.
+	avroNames map[string]string
+	// deferredResolved is the number of deferred types that have been processed.
+	deferredResolved int
+
+	needPasswordType bool
+	// civilTypes are the formats in civilFormats whose generated types are used.
+	civilTypes stringset.StringSet
+	// formatTypes maps the Go types for formats that are declared in other packages, such as time.Time, to their import
+	// paths.
+	formatTypes map[string]string
+	// importNames are the package names of the imports whose names can differ from the last element of their paths.
+	importNames map[string]string
+	// formatGoTypes maps string formats other than date-time to the Go types for them as they are referred to in
+	// generated code.
+	formatGoTypes map[string]string
+	// typeMappings maps formats to the Go types from TypeMappings as they are referred to in generated code.
+	typeMappings map[string]string
+
+	externalSchemas *schemaLoader
+	// initialisms are the words, in uppercase, that identifiers keep in uppercase, such as ID and URL.
+	initialisms stringset.StringSet
+	// externalRefs are the references to other documents whose types have been processed or deferred.
+	externalRefs stringset.StringSet
+	// rootSchema is the root document, which processLocalRef finds the subschemas that local references refer to in.
+	rootSchema *metaSchema
+	// localRefs are the references to subschemas of the root document that processLocalRef has processed or deferred.
+	localRefs stringset.StringSet
+	// printPkg is the import path of the package whose files are being printed, or "" for the generated package.
+	printPkg string
+	// quiet turns off warnings, for processing a schema whose warnings Generate has already logged.
+	quiet bool
+}
+
+func newGenerator(opts Options) *generator {
+	if opts.PackageName == "" {
+		opts.PackageName = "main"
+	}
+	if len(opts.Tags) == 0 {
+		opts.Tags = []string{"json"}
+	}
+	if opts.FieldSort == FieldSortSchemaOrder {
+		opts.FieldSort = FieldSortSchema
+	}
+	if opts.Command == "" {
+		opts.Command = "schematyper"
+	}
+	if opts.Banner == "" {
+		opts.Banner = fmt.Sprintf("Code generated by %s. DO NOT EDIT.", opts.Command)
+	} else if !opts.NoBanner && !generatedPattern.MatchString(fileComment(opts.Banner)) {
+		log.Printf("Banner %q doesn't match %s, so tools won't treat generated files as generated\n", opts.Banner, generatedPattern)
+	}
+	initialisms := stringset.New()
+	if !opts.NoDefaultInitialisms {
+		initialisms = stringset.New(commonInitialisms.Sorted()...)
+	}
+	for _, initialism := range opts.Initialisms {
+		initialisms.Add(strings.ToUpper(initialism))
+	}
+	g := &generator{
+		Options:         opts,
+		initialisms:     initialisms,
+		types:           make(map[string]goType),
+		deferredTypes:   make(map[string]deferredType),
+		typesByName:     make(stringSetMap),
+		transitiveRefs:  make(map[string]string),
+		renamedTypes:    make(map[string]string),
+		avroNames:       make(map[string]string),
+		externalSchemas: newSchemaLoader(ioutil.ReadFile),
+		externalRefs:    stringset.New(),
+		localRefs:       stringset.New(),
+		formatTypes:     map[string]string{typeTime: "time"},
+		importNames:     make(map[string]string),
+		formatGoTypes:   make(map[string]string),
+		typeMappings:    make(map[string]string),
+		civilTypes:      stringset.New(),
+	}
+	return g
+}
+
+// nameRoot sets RootTypeName, if it isn't set, from SchemaName, or from id, the ID of the schema, if SchemaName isn't
+// set either.
+func (g *generator) nameRoot(id string) {
+	if g.RootTypeName != "" {
+		return
+	}
+	name := g.SchemaName
+	if name == "" {
+		name = idName(id)
+	}
+	if name == "" {
+		name = "schema"
+	}
+	g.RootTypeName = g.generateIdentifier(name, g.exportTypes())
+}
+
+// IDName returns the name given to the JSON schema in schemaJSON by its $id, or its id in draft 4, which is the last
+// segment of the ID's path without extensions, e.g. "user-profile" for https://example.com/schemas/user-profile.json.
+// It returns "" if the schema has no ID, or the ID has no path.
+func IDName(schemaJSON []byte) string {
+	var s metaSchema
+	if err := json.Unmarshal(schemaJSON, &s); err != nil {
+		return ""
+	}
+	return idName(schemaID(&s))
+}
+
+// schemaID returns the ID of s: its $id, or its id in draft 4.
+func schemaID(s *metaSchema) string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return s.LegacyID
+}
+
+func idName(id string) string {
+	u, err := url.Parse(id)
+	if err != nil {
+		return ""
+	}
+	base := path.Base(u.Path)
+	if base == "." || base == "/" {
+		return ""
+	}
+	return strings.Split(base, ".")[0]
+}
+
+// generateError is a panic value used by fail to stop processing, which is recursive, and return the error from
+// Generate.
+type generateError struct {
+	err error
+}
+
+func (g *generator) fail(format string, args ...interface{}) {
+	panic(generateError{fmt.Errorf(format, args...)})
+}
+
+// warnf logs a warning about the schema, such as a keyword that is ignored, unless the generator is quiet.
+func (g *generator) warnf(format string, args ...interface{}) {
+	if !g.quiet {
+		log.Printf(format, args...)
+	}
+}
+
+// Generate returns the formatted Go source of the types described by schemaJSON. If formatting fails, the unformatted
+// source is returned along with the error. Each call has its own state, so calls can run concurrently.
+func Generate(schemaJSON []byte, opts Options) (src []byte, err error) {
+	if opts.DefinitionsPackage != "" {
+		return nil, errors.New("DefinitionsPackage needs GenerateFiles, since a single file can't hold two packages")
+	}
+	g := newGenerator(opts)
+	defer g.recoverError(&err)
+
+	if err = g.process(schemaJSON); err != nil {
+		return nil, err
+	}
+	if src, err = g.render(); err != nil {
+		return src, err
+	}
+	if g.Summary != nil {
+		if err = g.writeSummary(g.Summary, src); err != nil {
+			return nil, err
+		}
+	}
+	if g.Stats != nil {
+		if err = g.writeStats(g.Stats); err != nil {
+			return nil, err
+		}
+	}
+	return src, nil
+}
+
+// GenerateFiles is like Generate, but returns a formatted file for each type, keyed by a file name made from the
+// type's name in snake case, such as user_id.go for userID. Helpers shared by the types, such as the Ptr function,
+// get their own files. Each file imports only the packages it uses. With DefinitionsPackage, the files of that package
+// are keyed by paths in a directory named after it, such as types/address.go. Summary and Stats aren't written.
+func GenerateFiles(schemaJSON []byte, opts Options) (files map[string][]byte, err error) {
+	g := newGenerator(opts)
+	defer g.recoverError(&err)
+
+	if err = g.process(schemaJSON); err != nil {
+		return nil, err
+	}
+	return g.renderFiles()
+}
+
+// roundTripTest is the test written by GenerateTest, formatted with the names of the test and the root type.
+const roundTripTest = `func Test%sRoundTrip(t *testing.T) {
+	var v %s
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshalling %%T: %%s", v, err)
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("unmarshalling %%s into %%T: %%s", data, v, err)
+	}
+}
+`
+
+// GenerateTest returns a formatted test file for the types Generate returns for schemaJSON, in the same package,
+// which marshals the zero value of the root type to JSON and unmarshals it back. It is a smoke test that the types
+// compile and work with encoding/json, e.g. after the schema changes.
+func GenerateTest(schemaJSON []byte, opts Options) (src []byte, err error) {
+	// Generate warns about the banner and the schema, so the warnings aren't logged twice
+	banner := opts.Banner
+	opts.Banner = ""
+	g := newGenerator(opts)
+	if banner != "" {
+		g.Banner = banner
+	}
+	g.quiet = true
+	defer g.recoverError(&err)
+
+	if err = g.process(schemaJSON); err != nil {
+		return nil, err
+	}
+	rootPath, ok := g.rootTypePath()
+	if !ok {
+		return nil, fmt.Errorf("the root type %s isn't generated, since OnlyTypes doesn't name it or a type referring to it, so it can't be tested", g.RootTypeName)
+	}
+	if g.rejectsZeroValue(rootPath, stringset.New()) {
+		return nil, fmt.Errorf("EnumMarshalCheck rejects the zero value of %s, which has enums that aren't set, so it can't be tested", g.RootTypeName)
+	}
+	body := fmt.Sprintf(roundTripTest, g.generateIdentifier(g.RootTypeName, true), g.RootTypeName)
+	return g.formatFile([]byte(body), []string{"encoding/json", "testing"})
+}
+
+// rootTypePath returns the path of the type named RootTypeName, which Avro schemas don't have at #, and false if it
+// was left out.
+func (g *generator) rootTypePath() (string, bool) {
+	for path, gt := range g.types {
+		if gt.Name == g.RootTypeName {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// rejectsZeroValue returns true if marshalling the zero value of the type at path fails because EnumMarshalCheck
+// rejects an enum that isn't one of its constants: the type itself, or a field that is marshalled even when it is
+// zero. seen holds the paths of the types being checked, which is how recursive types end.
+func (g *generator) rejectsZeroValue(path string, seen stringset.StringSet) bool {
+	gt, ok := g.types[path]
+	if !g.EnumMarshalCheck || !ok || seen.Has(path) {
+		return false
+	}
+	seen.Add(path)
+	if len(gt.Enum) > 0 {
+		for _, val := range gt.Enum {
+			if (gt.TypePrefix == typeString && val == "") || (gt.TypePrefix != typeString && fmt.Sprint(val) == "0") {
+				return false
+			}
+		}
+		return true
+	}
+	if gt.TypePrefix == "" {
+		return g.rejectsZeroValue(gt.TypeRef, seen)
+	}
+	if gt.TypePrefix != typeStruct || gt.hasUnexportedFields() {
+		return false
+	}
+	for _, sf := range gt.Fields {
+		if _, ok := sf.ExtraTags["json"]; ok || sf.TypeRef == "" || sf.TypePrefix != "" || sf.Overflow || g.isPointer(sf) {
+			continue
+		}
+		omitted := !sf.Embedded && !sf.Required && !g.NoOmitEmpty
+		if omitted && g.types[sf.TypeRef].TypePrefix != typeStruct {
+			// omitempty leaves out the zero values of scalars, but not of structs
+			continue
+		}
+		if g.rejectsZeroValue(sf.TypeRef, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// recoverError recovers from a panic raised by fail, setting *err to its error.
+func (g *generator) recoverError(err *error) {
+	if r := recover(); r != nil {
+		genErr, ok := r.(generateError)
+		if !ok {
+			panic(r)
+		}
+		*err = genErr.err
+	}
+}
+
+// process parses schemaJSON and processes the types it describes.
+func (g *generator) process(schemaJSON []byte) error {
+	if g.MaxNameLength < 0 || (g.MaxNameLength > 0 && g.MaxNameLength <= nameHashLength) {
+		return fmt.Errorf("MaxNameLength must be more than %d, the length of the hash ending abbreviated names", nameHashLength)
+	}
+	if g.DefinitionsPackage != "" {
+		if g.Unexported {
+			return errors.New("the types in DefinitionsPackage are referred to from another package, so they can't be unexported")
+		}
+		g.importNames[g.DefinitionsPackage] = importPathName(g.DefinitionsPackage)
+	}
+	for format, goType := range map[string]string{"uuid": g.UUIDType, "date": g.DateType, "time": g.TimeType} {
+		if _, mapped := g.TypeMappings[format]; goType == "" || mapped {
+			// a type mapping takes precedence, and its package may have the same name
+			continue
+		}
+		if err := g.setFormatType(format, goType); err != nil {
+			return fmt.Errorf("%s type: %s", format, err)
+		}
+	}
+	for format, goType := range g.TypeMappings {
+		if err := g.setTypeMapping(format, goType); err != nil {
+			return fmt.Errorf("type mapping for %s: %s", format, err)
+		}
+	}
+	if g.Avro {
+		s, err := parseAvro(schemaJSON)
+		if err != nil {
+			return fmt.Errorf("parsing Avro schema: %s", err)
+		}
+		if err = g.processAvro(s); err != nil {
+			return fmt.Errorf("processing Avro schema: %s", err)
+		}
+		return nil
+	}
+	if err := g.checkKeywords(schemaJSON); err != nil {
+		return err
+	}
+	s, err := parseSchema(schemaJSON, g.RefBaseDir, "")
+	if err != nil {
+		return fmt.Errorf("parsing JSON: %s", err)
+	}
+	g.nameRoot(schemaID(s))
+	g.generate(s)
+	return nil
+}
+
+// Identifier returns name as a Go identifier, e.g. "user-id" becomes "userID", or "UserID" if exported is true. It
+// uses the default initialisms.
+func Identifier(name string, exported bool) string {
+	return newGenerator(Options{}).generateIdentifier(name, exported)
+}
+
+// ReverseSchema returns a JSON schema, indented for reading, for the Go type typeName in the package in dir.
+func ReverseSchema(dir, typeName string) ([]byte, error) {
+	s, err := reverseSchema(dir, typeName)
+	if err != nil {
+		return nil, err
+	}
+	schemaJSON, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(schemaJSON, '\n'), nil
+}
@@ -0,0 +1,487 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGenerate(t *testing.T) {
+	Convey("Given a schema", t, func() {
+		schema := []byte(`{"type": "object", "properties": {"user-id": {"type": "integer"}}}`)
+
+		Convey("When we generate with the zero Options", func() {
+			src, err := Generate(schema, Options{})
+
+			Convey("Then the defaults should be used", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldStartWith, "// Code generated by schematyper. DO NOT EDIT.\n\npackage main\n")
+				So(compact(string(src)), ShouldContainSubstring, "type schema struct {\n UserID int `json:\"user-id,omitempty\"`\n}")
+			})
+		})
+
+		Convey("When we generate concurrently with different options", func() {
+			packages := []string{"alpha", "beta", "gamma", "delta"}
+			srcs := make([]string, len(packages))
+			var wg sync.WaitGroup
+			for i, pkg := range packages {
+				wg.Add(1)
+				go func(i int, pkg string) {
+					defer wg.Done()
+					src, _ := Generate(schema, Options{PackageName: pkg, SchemaName: pkg + "-record"})
+					srcs[i] = string(src)
+				}(i, pkg)
+			}
+			wg.Wait()
+
+			Convey("Then each call should only see its own options and types", func() {
+				for i, pkg := range packages {
+					So(srcs[i], ShouldContainSubstring, "\npackage "+pkg+"\n")
+					So(srcs[i], ShouldContainSubstring, "type "+Identifier(pkg+"-record", true)+" struct")
+					So(strings.Count(srcs[i], "\ntype "), ShouldEqual, 1)
+				}
+			})
+		})
+	})
+
+	Convey("Given a schema with a reference that can't be resolved", t, func() {
+		schema := []byte(`{"type": "object", "properties": {"owner": {"$ref": "#/definitions/missing"}}}`)
+
+		Convey("When we generate", func() {
+			_, err := Generate(schema, Options{})
+
+			Convey("Then there should be an error instead of an exit", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "can't resolve")
+			})
+		})
+	})
+}
+
+func TestMetaSchemaGenerated(t *testing.T) {
+	Convey("Given the meta-schema", t, func() {
+		schema, err := os.ReadFile("metaschema.json")
+		So(err, ShouldBeNil)
+		committed, err := os.ReadFile("metaschema_schematype.go")
+		So(err, ShouldBeNil)
+
+		Convey("When we generate it as go generate does", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			src, err := Generate(schema, Options{PackageName: "gen", RootTypeName: "metaSchema", TypeNamesPrefix: "meta"})
+
+			Convey("Then it should match the committed source", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldEqual, string(committed))
+			})
+		})
+	})
+}
+
+func TestAnySchemas(t *testing.T) {
+	Convey("Given schemas that allow any value", t, func() {
+		Convey("When we generate from the empty schema and from true", func() {
+			for _, schema := range []string{`{}`, `true`, " true\n"} {
+				src, err := Generate([]byte(schema), Options{})
+
+				Convey("Then the root type should be the empty interface for "+strconv.Quote(schema), func() {
+					So(err, ShouldBeNil)
+					So(string(src), ShouldEqual, "// Code generated by schematyper. DO NOT EDIT.\n\npackage main\n\ntype schema interface{}\n")
+				})
+			}
+		})
+
+		Convey("When we generate from true with UseAny", func() {
+			src, err := Generate([]byte(`true`), Options{UseAny: true})
+
+			Convey("Then the root type should be any", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldContainSubstring, "type schema any\n")
+			})
+		})
+
+		Convey("When we generate a test for true", func() {
+			src, err := GenerateTest([]byte(`true`), Options{})
+
+			Convey("Then it should round-trip the root type", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldContainSubstring, "var v schema\n")
+			})
+		})
+	})
+
+	Convey("Given the schema false, which allows no value", t, func() {
+		Convey("When we generate", func() {
+			_, err := Generate([]byte(`false`), Options{})
+			_, testErr := GenerateTest([]byte(`false`), Options{})
+
+			Convey("Then there should be an error, since it has no type", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "the schema is false")
+				So(testErr, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestIDName(t *testing.T) {
+	Convey("Given schemas with IDs", t, func() {
+		Convey("Then their names should be the last segments of the IDs' paths without extensions", func() {
+			So(IDName([]byte(`{"$id": "https://example.com/schemas/user-profile.json"}`)), ShouldEqual, "user-profile")
+			So(IDName([]byte(`{"id": "http://example.com/order.schema.json#"}`)), ShouldEqual, "order")
+			So(IDName([]byte(`{"$id": "https://example.com/a.json", "id": "https://example.com/b.json"}`)), ShouldEqual, "a")
+			So(IDName([]byte(`{"$id": "https://example.com/"}`)), ShouldEqual, "")
+			So(IDName([]byte(`{"$id": "urn:example:thing"}`)), ShouldEqual, "")
+			So(IDName([]byte(`{"type": "object"}`)), ShouldEqual, "")
+		})
+
+		Convey("When we generate without a schema name", func() {
+			src, err := Generate([]byte(`{"$id": "https://example.com/schemas/user-profile.json", "type": "object", "properties": {"name": {"type": "string"}}}`), Options{PackageName: "profiles"})
+
+			Convey("Then the root type should be named after the ID", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldContainSubstring, "type UserProfile struct")
+			})
+		})
+
+		Convey("When we generate with a schema name", func() {
+			src, err := Generate([]byte(`{"$id": "https://example.com/schemas/user-profile.json", "type": "string"}`), Options{SchemaName: "account"})
+
+			Convey("Then the root type should be named after it", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldContainSubstring, "type account string")
+			})
+		})
+	})
+}
+
+func TestInitialisms(t *testing.T) {
+	Convey("Given a schema with domain acronyms in its names", t, func() {
+		schema := []byte(`{"type": "object", "properties": {"skuCode": {"type": "string"}, "vin": {"type": "string"}, "userId": {"type": "string"}}}`)
+
+		Convey("When we generate with the default initialisms", func() {
+			src, err := Generate(schema, Options{SchemaName: "acme-order"})
+
+			Convey("Then only the default initialisms should be uppercase", func() {
+				So(err, ShouldBeNil)
+				So(compact(string(src)), ShouldContainSubstring, "type acmeOrder struct {\n SkuCode string `json:\"skuCode,omitempty\"`\n UserID string `json:\"userId,omitempty\"`\n Vin string")
+			})
+		})
+
+		Convey("When we generate with custom initialisms", func() {
+			src, err := Generate(schema, Options{SchemaName: "acme-order", Initialisms: []string{"ACME", "sku", "Vin"}})
+
+			Convey("Then they should be uppercase regardless of how they were given", func() {
+				So(err, ShouldBeNil)
+				So(compact(string(src)), ShouldContainSubstring, "type acmeOrder struct {\n SKUCode string `json:\"skuCode,omitempty\"`\n UserID string `json:\"userId,omitempty\"`\n VIN string")
+			})
+
+			Convey("Then other calls should still use the default initialisms", func() {
+				So(Identifier("sku", true), ShouldEqual, "Sku")
+			})
+		})
+
+		Convey("When we generate without the default initialisms", func() {
+			src, err := Generate(schema, Options{PackageName: "orders", SchemaName: "acme-order", Initialisms: []string{"acme"}, NoDefaultInitialisms: true})
+
+			Convey("Then only the custom initialisms should be uppercase", func() {
+				So(err, ShouldBeNil)
+				So(compact(string(src)), ShouldContainSubstring, "type ACMEOrder struct {\n SkuCode string `json:\"skuCode,omitempty\"`\n UserId string `json:\"userId,omitempty\"`\n Vin string")
+			})
+		})
+	})
+}
+
+func TestUnsupportedKeywords(t *testing.T) {
+	Convey("Given a schema with keywords that aren't supported", t, func() {
+		schema := []byte(`{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "not": {"const": ""}},
+				"options": {"type": "object", "default": {"if": true}},
+				"size": {"anyOf": [{"type": "integer"}, {"type": "string", "not": {"const": ""}}]},
+				"tags": {"type": "array", "items": {"type": "string"}, "contains": {"const": "x"}, "minItems": 1}
+			},
+			"definitions": {
+				"if": {"type": "string"},
+				"shape": {"if": {"properties": {"kind": {"const": "circle"}}}, "then": {"required": ["radius"]}}
+			},
+			"propertyNames": {"pattern": "^[a-z]+$"}
+		}`)
+
+		Convey("When we generate", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			src, err := Generate(schema, Options{})
+
+			Convey("Then each keyword should be logged with its path, and the types generated without them", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldContainSubstring, "type schema struct")
+				So(logs.String(), ShouldContainSubstring, "Ignoring unsupported keyword propertyNames at # and everything in it\n")
+				So(logs.String(), ShouldContainSubstring, "Ignoring unsupported keyword if at #/definitions/shape and everything in it\n")
+				So(logs.String(), ShouldContainSubstring, "Ignoring unsupported keyword then at #/definitions/shape and everything in it\n")
+				So(logs.String(), ShouldContainSubstring, "Ignoring unsupported keyword not at #/properties/name and everything in it\n")
+				So(logs.String(), ShouldContainSubstring, "Ignoring unsupported keyword contains at #/properties/tags and everything in it\n")
+				So(logs.String(), ShouldContainSubstring, "Ignoring unsupported keyword anyOf at #/properties/size and everything in it\n")
+			})
+
+			Convey("Then definitions named like keywords and value constraints should not be logged", func() {
+				So(logs.String(), ShouldNotContainSubstring, "#/definitions\n")
+				So(logs.String(), ShouldNotContainSubstring, "minItems")
+				So(strings.Count(logs.String(), "Ignoring unsupported keyword"), ShouldEqual, 6)
+			})
+		})
+
+		Convey("When we generate with StrictKeywords", func() {
+			_, err := Generate(schema, Options{StrictKeywords: true})
+
+			Convey("Then it should fail, listing the keywords", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "unsupported keywords: propertyNames at # and everything in it, if at #/definitions/shape and everything in it, then at #/definitions/shape and everything in it, not at #/properties/name and everything in it, anyOf at #/properties/size and everything in it, contains at #/properties/tags and everything in it")
+			})
+		})
+	})
+}
+
+func TestDependentRequired(t *testing.T) {
+	Convey("Given a schema with property dependencies", t, func() {
+		schema := []byte(`{
+			"type": "object",
+			"description": "A payment.",
+			"properties": {
+				"creditCard": {"type": "string"},
+				"billingAddress": {"type": "string"},
+				"cvv": {"type": "string"},
+				"coupon": {"type": "string"},
+				"discount": {"type": "number"}
+			},
+			"dependentRequired": {"creditCard": ["billingAddress", "cvv"]},
+			"dependencies": {
+				"coupon": ["discount"],
+				"discount": {"required": ["coupon"]}
+			}
+		}`)
+
+		Convey("When we generate", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			src, err := Generate(schema, Options{})
+
+			Convey("Then the type's comment should note the required properties, sorted", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldContainSubstring, "// A payment.\n"+
+					"// If \"coupon\" is present, \"discount\" is required.\n"+
+					"// If \"creditCard\" is present, \"billingAddress\" and \"cvv\" are required.\n"+
+					"type schema struct")
+			})
+
+			Convey("Then only the schema dependency should be logged as unsupported", func() {
+				So(logs.String(), ShouldContainSubstring, "Ignoring unsupported keyword dependencies at # and everything in it\n")
+				So(logs.String(), ShouldNotContainSubstring, "dependentRequired")
+			})
+		})
+
+		Convey("When the dependencies only list properties", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			_, err := Generate([]byte(`{
+				"type": "object",
+				"properties": {"a": {"type": "string"}, "b": {"type": "string"}},
+				"dependencies": {"a": ["b"]}
+			}`), Options{StrictKeywords: true})
+
+			Convey("Then they should be supported", func() {
+				So(err, ShouldBeNil)
+				So(logs.String(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestBanner(t *testing.T) {
+	Convey("Given a schema", t, func() {
+		schema := []byte(`{"type": "string"}`)
+
+		Convey("When we generate with a banner that tools don't recognize", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			src, err := Generate(schema, Options{Banner: "generated -- do not edit"})
+
+			Convey("Then it should be used, with a warning", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldStartWith, "// generated -- do not edit\n\npackage main\n")
+				So(logs.String(), ShouldContainSubstring, "tools won't treat generated files as generated")
+			})
+		})
+
+		Convey("When we generate with a banner in the standard form", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			_, err := Generate(schema, Options{Banner: "// Code generated by make schemas. DO NOT EDIT."})
+
+			Convey("Then there should be no warning", func() {
+				So(err, ShouldBeNil)
+				So(logs.String(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestGenerateTest(t *testing.T) {
+	Convey("Given a schema", t, func() {
+		schema := []byte(`{
+			"$id": "https://example.com/schemas/user-sku.json",
+			"type": "object",
+			"properties": {"name": {"type": "string"}}
+		}`)
+		opts := Options{PackageName: "models", Initialisms: []string{"SKU"}, BuildTags: "generated"}
+
+		Convey("When we generate the types and their test", func() {
+			src, err := Generate(schema, opts)
+			So(err, ShouldBeNil)
+			testSrc, err := GenerateTest(schema, opts)
+
+			Convey("Then the test should round-trip the root type, named as it is in the types", func() {
+				So(err, ShouldBeNil)
+				So(string(src), ShouldContainSubstring, "type UserSKU struct")
+				So(string(testSrc), ShouldContainSubstring, "func TestUserSKURoundTrip(t *testing.T) {\n\tvar v UserSKU\n")
+				So(string(testSrc), ShouldContainSubstring, "json.Unmarshal(data, &v)")
+			})
+
+			Convey("Then it should have the header of the types' file", func() {
+				So(string(testSrc), ShouldStartWith, "// Code generated by schematyper. DO NOT EDIT.\n\n//go:build generated\n\npackage models\n")
+			})
+
+			Convey("Then it should compile with the types", func() {
+				srcs := []string{string(src), string(testSrc)}
+				for i, s := range srcs {
+					srcs[i] = strings.Replace(s, "package models", "package main", 1)
+				}
+				So(typeCheck(srcs...), ShouldBeNil)
+			})
+		})
+
+		Convey("When OnlyTypes leaves out the root type", func() {
+			_, err := GenerateTest(schema, Options{OnlyTypes: []string{"name"}})
+
+			Convey("Then there should be an error, since there is no root type to test", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When we generate the test with a banner that tools don't recognize", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			testSrc, err := GenerateTest(schema, Options{Banner: "generated -- do not edit"})
+
+			Convey("Then it should be used, without a warning, which Generate logs", func() {
+				So(err, ShouldBeNil)
+				So(string(testSrc), ShouldStartWith, "// generated -- do not edit\n\npackage main\n")
+				So(logs.String(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a schema whose definitions refer back to the root", t, func() {
+		schema := []byte(`{
+			"type": "object",
+			"properties": {"owner": {"$ref": "#/definitions/owner"}},
+			"definitions": {
+				"owner": {"type": "object", "properties": {"pets": {"type": "array", "items": {"$ref": "#"}}}},
+				"tag": {"type": "string"}
+			}
+		}`)
+
+		Convey("When OnlyTypes names a type that refers to the root type", func() {
+			testSrc, err := GenerateTest(schema, Options{OnlyTypes: []string{"owner"}})
+
+			Convey("Then the root type should be tested, since it is generated", func() {
+				So(err, ShouldBeNil)
+				So(string(testSrc), ShouldContainSubstring, "var v schema\n")
+			})
+		})
+
+		Convey("When OnlyTypes only names a type that doesn't", func() {
+			_, err := GenerateTest(schema, Options{OnlyTypes: []string{"tag"}})
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "root type schema isn't generated")
+			})
+		})
+	})
+
+	Convey("Given a schema with enums", t, func() {
+		schema := `{
+			"type": "object",
+			"properties": {
+				"status": {"type": "string", "enum": ["active", "inactive"]},
+				"level": {"type": "integer", "enum": [0, 1]},
+				"address": {"type": "object", "properties": {"kind": {"$ref": "#/definitions/kind"}}, "required": [%s]}
+			},
+			"required": [%s],
+			"definitions": {"kind": {"type": "string", "enum": ["home", "work"]}}
+		}`
+		opts := Options{EnumMarshalCheck: true}
+
+		Convey("When we generate the test with EnumMarshalCheck and the enums are optional", func() {
+			_, err := GenerateTest([]byte(fmt.Sprintf(schema, ``, `"level"`)), opts)
+
+			Convey("Then there should be no error, since the zero values are left out or are constants", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When a required enum has no constant for its zero value", func() {
+			_, err := GenerateTest([]byte(fmt.Sprintf(schema, ``, `"status"`)), opts)
+
+			Convey("Then there should be an error instead of a test that fails", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "EnumMarshalCheck rejects the zero value of schema")
+			})
+		})
+
+		Convey("When a struct that is marshalled even if it is optional has a required enum", func() {
+			_, err := GenerateTest([]byte(fmt.Sprintf(schema, `"kind"`, ``)), opts)
+
+			Convey("Then there should be an error too", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When we generate the test without EnumMarshalCheck", func() {
+			_, err := GenerateTest([]byte(fmt.Sprintf(schema, `"kind"`, `"status"`)), Options{})
+
+			Convey("Then there should be no error", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given an Avro schema", t, func() {
+		schema := []byte(`{"type": "record", "name": "Event", "fields": [{"name": "id", "type": "long"}]}`)
+
+		Convey("When we generate the test", func() {
+			testSrc, err := GenerateTest(schema, Options{Avro: true})
+
+			Convey("Then it should use the root type named by the schema", func() {
+				So(err, ShouldBeNil)
+				So(string(testSrc), ShouldContainSubstring, "var v event\n")
+			})
+		})
+	})
+}
@@ -0,0 +1,3104 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"go/token"
+	gotypes "go/types"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gedex/inflector"
+	"github.com/idubinskiy/schematyper/stringset"
+)
+
+//go:generate schematyper --package=gen --root-type=metaSchema --prefix=meta metaschema.json
+
+type structField struct {
+	Name         string
+	TypeRef      string
+	TypePrefix   string
+	Nullable     bool
+	PropertyName string
+	Required     bool
+	Embedded     bool
+	Unexported   bool
+	Overflow     bool
+	PtrForOmit   bool
+	ExtraTags    map[string]string
+	Comment      string
+	Recursive    bool
+	// Validate is the rule for the validate tag, and Pattern the pattern the value must match, if ValidateTags is set.
+	Validate string
+	Pattern  string
+	// Access is accessRead for a read-only property and accessWrite for a write-only one.
+	Access string
+	// Default is the default value of the property, if any, which the constructor sets if Constructors is set.
+	Default interface{}
+	// Deprecated is the deprecation notice of a deprecated property.
+	Deprecated string
+	// Example is the comment showing an example value of a field whose type isn't generated from its property.
+	Example string
+	// Order is the position of the field in the schema, which is used with FieldSortSchema.
+	Order int
+	// JSONString is true for a number or boolean that is encoded as a JSON string, which gets the json tag's string
+	// option.
+	JSONString bool
+}
+
+// tags returns the struct tags for the field, including the enclosing backticks: one for each of the Tags libraries,
+// and bson if BSONTags is set, followed by the extra tags. Embedded fields have no tags so that their fields are
+// promoted when marshalling, and overflow fields are hidden from the libraries, which leaves them to custom
+// marshalling. Optional fields are omitempty unless NoOmitEmpty is set, and numbers and booleans encoded as strings
+// have the string option in their json tag.
+//
+// Read-only and write-only fields get an access tag if AccessTags is set.
+func (g *generator) tags(sf structField) string {
+	if sf.Unexported {
+		// encoding/json ignores unexported fields, so their tags are on the fields of the wrapper in their type's codec
+		return ""
+	}
+
+	libs := g.Tags
+	if g.BSONTags && !stringset.New(libs...).Has("bson") {
+		libs = append(append([]string{}, libs...), "bson")
+	}
+	if sf.Embedded {
+		// encoding/json promotes the fields of embedded structs, but the MongoDB driver only does so when told to
+		if stringset.New(libs...).Has("bson") {
+			return "`bson:\",inline\"`"
+		}
+		return ""
+	}
+	var tags []string
+	for _, lib := range libs {
+		if _, ok := sf.ExtraTags[lib]; ok {
+			continue
+		}
+		libTag := "-"
+		if !sf.Overflow {
+			libTag = g.tagKey(sf.PropertyName)
+			if lib == "bson" && g.BSONTags {
+				// MongoDB documents conventionally use lowercase keys
+				libTag = strings.ToLower(libTag)
+			}
+			if !sf.Required && !g.NoOmitEmpty {
+				libTag += ",omitempty"
+			}
+			if lib == "json" && sf.JSONString {
+				libTag += ",string"
+			}
+		}
+		tags = append(tags, fmt.Sprintf("%s:%q", lib, libTag))
+	}
+
+	if g.ValidateTags && sf.Validate != "" {
+		if _, ok := sf.ExtraTags["validate"]; !ok {
+			rule := sf.Validate
+			if !sf.Required {
+				// the rule only applies to optional fields that are set
+				rule = "omitempty," + rule
+			}
+			tags = append(tags, fmt.Sprintf("validate:%q", rule))
+		}
+	}
+	if g.AccessTags && sf.Access != "" {
+		if _, ok := sf.ExtraTags["access"]; !ok {
+			tags = append(tags, fmt.Sprintf("access:%q", sf.Access))
+		}
+	}
+
+	// extra tags follow the library tags, sorted by key so output is stable; they replace a library's tag
+	extraTagKeys, _ := stringset.FromMapKeys(sf.ExtraTags)
+	for _, key := range extraTagKeys.Sorted() {
+		tags = append(tags, fmt.Sprintf("%s:%q", key, sf.ExtraTags[key]))
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return "`" + strings.Join(tags, " ") + "`"
+}
+
+// validateRule returns the rule for a go-playground/validator validate tag for the constraints of s, or "" if it has
+// none. The validator applies min and max to the length of strings and slices and to the value of numbers, so:
+//   - minLength and maxLength of strings, minItems and maxItems of arrays, and minimum and maximum of numbers become
+//     min and max;
+//   - an exclusive minimum or maximum becomes gt or lt.
+//
+// The validator can't take an arbitrary regular expression in a tag, so pattern is documented in a comment instead
+// (see fieldComment). A json.Number is a string to the validator, so minimum and maximum of numbers are left out under
+// --number-type=json.Number.
+func (g *generator) validateRule(s *metaSchema) string {
+	var rules []string
+	addRule := func(name string, val float64) {
+		rules = append(rules, name+"="+strconv.FormatFloat(val, 'f', -1, 64))
+	}
+	switch schemaJSONType(s) {
+	case typeString:
+		if minLength, ok := s.MinLength.(float64); ok && minLength > 0 {
+			addRule("min", minLength)
+		}
+		if s.MaxLength > 0 {
+			addRule("max", float64(s.MaxLength))
+		}
+	case typeArray:
+		if minItems, ok := s.MinItems.(float64); ok && minItems > 0 {
+			addRule("min", minItems)
+		}
+		if s.MaxItems > 0 {
+			addRule("max", float64(s.MaxItems))
+		}
+	case typeInteger, typeNumber:
+		if schemaJSONType(s) == typeNumber && g.NumberType == NumberTypeJSONNumber {
+			break
+		}
+		if s.Minimum != nil {
+			if s.ExclusiveMinimum {
+				addRule("gt", *s.Minimum)
+			} else {
+				addRule("min", *s.Minimum)
+			}
+		}
+		if s.Maximum != nil {
+			if s.ExclusiveMaximum {
+				addRule("lt", *s.Maximum)
+			} else {
+				addRule("max", *s.Maximum)
+			}
+		}
+	}
+	return strings.Join(rules, ",")
+}
+
+// schemaJSONType returns the JSON type of s, ignoring null, or "" if it doesn't have a single type.
+func schemaJSONType(s *metaSchema) string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		jsonType, _ := parseTypeArray(t)
+		return jsonType
+	}
+	return ""
+}
+
+// parseTypeArray returns the JSON type of a schema whose type is the array types, which is "" unless there is a single
+// type other than null, and whether null is one of the types.
+func parseTypeArray(types []interface{}) (jsonType string, nullable bool) {
+	var nonNull []string
+	for _, t := range types {
+		if t == typeNull {
+			nullable = true
+			continue
+		}
+		if t, ok := t.(string); ok {
+			nonNull = append(nonNull, t)
+		}
+	}
+	if len(nonNull) == 1 {
+		jsonType = nonNull[0]
+	}
+	return jsonType, nullable
+}
+
+// fieldComment returns the comment for the field, which notes the pattern its value must match if ValidateTags is set,
+// whether it is read-only or write-only if AccessComments is set, an example value, and a deprecation notice in its
+// own paragraph, as tools such as staticcheck expect.
+func (g *generator) fieldComment(sf structField) string {
+	comment := sf.Comment
+	if g.ValidateTags && sf.Pattern != "" {
+		patternComment := fmt.Sprintf("must match %s", sf.Pattern)
+		if comment == "" {
+			comment = patternComment
+		} else {
+			comment += "; " + patternComment
+		}
+	}
+	var lines []string
+	if g.AccessComments && sf.Access != "" {
+		lines = append(lines, sf.Access+"-only")
+	}
+	if comment != "" {
+		lines = append(lines, comment)
+	}
+	if sf.Example != "" {
+		lines = append(lines, sf.Example)
+	}
+	if sf.Deprecated != "" {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, sf.Deprecated)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// typeString returns the Go type of the field.
+func (g *generator) typeString(sf structField) string {
+	sfTypeStr := sf.TypePrefix
+	if _, ok := g.types[sf.TypeRef]; ok {
+		sfTypeStr += g.typeName(sf.TypeRef)
+	}
+	if !sf.Overflow {
+		// the overflow map is filled by the generated JSON methods, so it stays a map
+		sfTypeStr = g.mapTypeString(sfTypeStr)
+	}
+	sfTypeStr = g.anyType(sfTypeStr)
+	if g.isSliceOrMap(sf) {
+		// slices and maps are already nilable, so they're never pointers
+		return sfTypeStr
+	}
+	if sf.Recursive {
+		if g.RecursionStrategy == RecursionRawMessage && !sf.Embedded {
+			return "json.RawMessage"
+		}
+		return "*" + sfTypeStr
+	}
+	if g.isPointer(sf) {
+		sfTypeStr = "*" + sfTypeStr
+	}
+	return sfTypeStr
+}
+
+// anyType returns typeStr with interface{} written as any if UseAny is set. Types are kept as interface{} while they are
+// processed, so only the generated source changes.
+func (g *generator) anyType(typeStr string) string {
+	if !g.UseAny {
+		return typeStr
+	}
+	return strings.Replace(typeStr, typeEmptyInterface, "any", -1)
+}
+
+// isPointer returns true if the field is a pointer to its type, which depends on Pointers and PtrForOmit. Slices, maps,
+// and interfaces are already nilable, so they're never pointers.
+func (g *generator) isPointer(sf structField) bool {
+	typePrefix := g.basePrefix(sf)
+	if strings.HasPrefix(typePrefix, "[]") || strings.HasPrefix(typePrefix, "map[") ||
+		typePrefix == typeEmptyInterface || typePrefix == typeInterface {
+		return false
+	}
+	switch {
+	case sf.Nullable:
+		return g.Pointers != PointersNone
+	case sf.Embedded:
+		return false
+	case !sf.Required && g.Pointers == PointersOptional:
+		return true
+	}
+	return !sf.Required && g.PtrForOmit && sf.PtrForOmit
+}
+
+// isUntyped returns true if the field can hold any value because its schema doesn't restrict the type.
+func (sf structField) isUntyped() bool {
+	return sf.TypePrefix == typeEmptyInterface && sf.TypeRef == ""
+}
+
+// isSliceOrMap returns true if the field is a slice or map, either directly or through named types.
+func (g *generator) isSliceOrMap(sf structField) bool {
+	typePrefix := g.basePrefix(sf)
+	return strings.HasPrefix(typePrefix, "[]") || strings.HasPrefix(typePrefix, "map[")
+}
+
+// basePrefix returns the type prefix of the field, following named types to the first one that has a prefix.
+func (g *generator) basePrefix(sf structField) string {
+	typePrefix, typeRef := sf.TypePrefix, sf.TypeRef
+	for typePrefix == "" {
+		refType, ok := g.types[typeRef]
+		if !ok {
+			return ""
+		}
+		typePrefix, typeRef = refType.TypePrefix, refType.TypeRef
+	}
+	return typePrefix
+}
+
+// goName returns the name used to access the field, which for embedded fields is the name of their type.
+func (g *generator) goName(sf structField) string {
+	if sf.Embedded {
+		return g.generateIdentifier(g.types[sf.TypeRef].Name, true)
+	}
+	return sf.Name
+}
+
+// paramName returns a name for a function parameter holding the field's value that doesn't shadow a keyword, a
+// predeclared identifier, or any of the reserved names.
+func (g *generator) paramName(sf structField, reserved ...string) string {
+	name := g.generateIdentifier(g.goName(sf), false)
+	if token.IsKeyword(name) || gotypes.Universe.Lookup(name) != nil || stringset.New(reserved...).Has(name) {
+		name += "_"
+	}
+	return name
+}
+
+type structFields []structField
+
+func (s structFields) Len() int {
+	return len(s)
+}
+
+func (s structFields) Less(i, j int) bool {
+	return s[i].Name < s[j].Name
+}
+
+func (s structFields) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Values of Options.FieldSort.
+const (
+	FieldSortName          = "name"
+	FieldSortRequiredFirst = "required-first"
+	FieldSortSchema        = "schema"
+	// FieldSortSchemaOrder is another name for FieldSortSchema.
+	FieldSortSchemaOrder = "schema-order"
+)
+
+// Values of Options.JSONTagCase.
+const (
+	JSONTagCasePreserve = "preserve"
+	JSONTagCaseSnake    = "snake"
+	JSONTagCaseCamel    = "camel"
+)
+
+// Values of Options.Pointers.
+const (
+	PointersNullable = "nullable"
+	PointersOptional = "optional"
+	PointersNone     = "none"
+)
+
+// Values of Options.RecursionStrategy.
+const (
+	RecursionPointer    = "pointer"
+	RecursionRawMessage = "rawmessage"
+)
+
+// requiredFirstFields sorts required fields before optional ones, and by name within each group.
+type requiredFirstFields struct {
+	structFields
+}
+
+func (s requiredFirstFields) Less(i, j int) bool {
+	if s.structFields[i].Required != s.structFields[j].Required {
+		return s.structFields[i].Required
+	}
+	return s.structFields.Less(i, j)
+}
+
+// fieldsBySchemaOrder sorts fields by their position in the schema.
+type fieldsBySchemaOrder struct {
+	structFields
+}
+
+func (s fieldsBySchemaOrder) Less(i, j int) bool {
+	return s.structFields[i].Order < s.structFields[j].Order
+}
+
+type goType struct {
+	Name       string
+	TypeRef    string
+	TypePrefix string
+	Nullable   bool
+	Fields     structFields
+	Comment    string
+	Enum       []interface{}
+	// EnumNames are the names of the values of Enum from x-enum-varnames, in the same order, or nil.
+	EnumNames []string
+	EnumError bool
+	Variants  []string
+	// Registry maps the discriminator values of a oneOf to the paths of its variants.
+	Registry map[string]string
+	// Discriminator is the name of the property of a oneOf's variants that holds their discriminator value.
+	Discriminator string
+	// Closed is true for a struct whose schema has additionalProperties false.
+	Closed bool
+
+	parentPath     string
+	origTypeName   string
+	ambiguityDepth int
+	// pkg is the import path of the package the type is written to, or "" for the generated package.
+	pkg string
+}
+
+// printComment prints each line of comment as a line comment, with empty lines separating paragraphs. Lines are
+// wrapped at CommentWidth.
+func (g *generator) printComment(buf *bytes.Buffer, comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		if line == "" {
+			buf.WriteString("//\n")
+			continue
+		}
+		for _, wrapped := range wrapCommentLine(line, g.commentWidth()) {
+			buf.WriteString(fmt.Sprintf("// %s\n", wrapped))
+		}
+	}
+}
+
+// defaultCommentWidth is the width that comments are wrapped at if CommentWidth is 0.
+const defaultCommentWidth = 80
+
+// commentWidth returns the width that comments are wrapped at, or a negative width if they aren't wrapped.
+func (g *generator) commentWidth() int {
+	if g.CommentWidth == 0 {
+		return defaultCommentWidth
+	}
+	return g.CommentWidth
+}
+
+// wrapCommentLine splits line at spaces into lines that fit in width along with the "// " they are printed after.
+// Words are never broken, so a longer word, such as a URL, gets a line of its own. Indented lines, which godoc shows
+// as preformatted text, and all lines if width is negative, are kept as they are.
+func wrapCommentLine(line string, width int) []string {
+	if width < 0 || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return []string{line}
+	}
+	width -= len("// ")
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(line) {
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+func (g *generator) printType(buf *bytes.Buffer, gt goType) {
+	if gt.Comment != "" && !g.NoComments {
+		g.printComment(buf, gt.Comment)
+	}
+	if gt.TypePrefix == typeInterface {
+		g.printInterface(buf, gt)
+		return
+	}
+	typeStr := gt.TypePrefix
+	if _, ok := g.types[gt.TypeRef]; ok {
+		typeStr += g.typeName(gt.TypeRef)
+	}
+	typeStr = g.anyType(g.mapTypeString(typeStr))
+	buf.WriteString(fmt.Sprintf("type %s %s", gt.Name, typeStr))
+	if typeStr != typeStruct {
+		buf.WriteString("\n")
+		if len(gt.Enum) > 0 {
+			g.printEnum(buf, gt)
+		}
+		if typeStr == g.passwordTypeName() {
+			// methods aren't inherited by defined types
+			printRedactedMethods(buf, gt.Name)
+		}
+		if format, ok := g.civilFormat(typeStr); ok {
+			printCivilMethods(buf, gt.Name, format)
+		}
+		return
+	}
+	buf.WriteString(" {\n")
+	switch g.FieldSort {
+	case FieldSortRequiredFirst:
+		sort.Stable(requiredFirstFields{gt.Fields})
+	case FieldSortSchema:
+		sort.Stable(fieldsBySchemaOrder{gt.Fields})
+	default:
+		sort.Stable(gt.Fields)
+	}
+	for _, sf := range gt.Fields {
+		if comment := g.fieldComment(sf); comment != "" && !g.NoComments {
+			g.printComment(buf, comment)
+		}
+		buf.WriteString(fmt.Sprintf("%s %s %s\n", sf.Name, g.typeString(sf), g.tags(sf)))
+	}
+	buf.WriteString("}\n")
+}
+
+// markerMethodName returns the name of the unexported method that distinguishes the variants of a oneOf interface.
+func (g *generator) markerMethodName(gt goType) string {
+	return "is" + g.generateIdentifier(gt.Name, true)
+}
+
+// printInterface prints a oneOf as an interface with a marker method, which is then implemented by each variant, and
+// assertions that the variants implement it.
+func (g *generator) printInterface(buf *bytes.Buffer, gt goType) {
+	method := g.markerMethodName(gt)
+	buf.WriteString(fmt.Sprintf("type %s interface {\n%s()\n}\n", gt.Name, method))
+	for _, variantPath := range gt.Variants {
+		variant := g.types[variantPath]
+		if variant.pkg != gt.pkg {
+			// methods can only be declared in the package of their type
+			g.fail("oneOf %s and its variant %s must be in the same package", gt.Name, variant.Name)
+		}
+		buf.WriteString(fmt.Sprintf("\nfunc (%s) %s() {}\n", variant.Name, method))
+	}
+	if len(gt.Variants) == 0 {
+		return
+	}
+	// the package doesn't compile if a variant stops implementing the interface
+	buf.WriteString(fmt.Sprintf("\n// The variants of %s.\nvar (\n", gt.Name))
+	for _, variantPath := range gt.Variants {
+		buf.WriteString(fmt.Sprintf("_ %s = (*%s)(nil)\n", gt.Name, g.types[variantPath].Name))
+	}
+	buf.WriteString(")\n")
+
+	if len(gt.Registry) == 0 {
+		return
+	}
+	values := make([]string, 0, len(gt.Registry))
+	for value := range gt.Registry {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	registryName := gt.Name + "TypeRegistry"
+	buf.WriteString(fmt.Sprintf("\n// %s maps discriminator values to constructors of the variants of %s, which can then be\n", registryName, gt.Name))
+	buf.WriteString(fmt.Sprintf("// unmarshalled into.\nvar %s = map[string]func() %s{\n", registryName, gt.Name))
+	for _, value := range values {
+		buf.WriteString(fmt.Sprintf("%q: func() %s { return new(%s) },\n", value, gt.Name, g.types[gt.Registry[value]].Name))
+	}
+	buf.WriteString("}\n")
+}
+
+// discriminatorValues maps the discriminator values of the oneOf s at path to the paths of its variants, whose schemas
+// are in variantSchemas. A variant's value is its key in the discriminator's mapping, or else the name of the
+// definition it refers to, or else the const of its discriminator property.
+func (g *generator) discriminatorValues(s *metaSchema, path string, variants []string, variantSchemas map[string]*metaSchema) map[string]string {
+	values := make(map[string]string)
+	mapped := stringset.New()
+	mappedValues := make([]string, 0, len(s.Discriminator.Mapping))
+	for value := range s.Discriminator.Mapping {
+		mappedValues = append(mappedValues, value)
+	}
+	sort.Strings(mappedValues)
+	for _, value := range mappedValues {
+		ref := string(s.Discriminator.Mapping[value])
+		variantPath := g.discriminatorTarget(ref, variantSchemas)
+		if variantPath == "" {
+			g.warnf("Ignoring discriminator mapping of %q at %s: %s isn't a variant\n", value, path, ref)
+			continue
+		}
+		values[value] = variantPath
+		mapped.Add(variantPath)
+	}
+
+	property := s.Discriminator.PropertyName
+	for _, variantPath := range variants {
+		if mapped.Has(variantPath) {
+			continue
+		}
+		value, ok := definitionName(variantPath)
+		if !ok {
+			value, ok = constString(variantSchemas[variantPath].Properties[property])
+		}
+		if !ok {
+			g.warnf("Leaving %s out of the discriminator registry of %s: it has no discriminator value\n", g.types[variantPath].Name, path)
+			continue
+		}
+		if other, taken := values[value]; taken {
+			g.warnf("Leaving %s out of the discriminator registry of %s: %q is already the value of %s\n", g.types[variantPath].Name, path, value, g.types[other].Name)
+			continue
+		}
+		values[value] = variantPath
+	}
+	return values
+}
+
+// discriminatorTarget returns the path of the variant that ref, a value of a discriminator's mapping, refers to, or ""
+// if it isn't one of the variants in variantSchemas. A bare name refers to a definition.
+func (g *generator) discriminatorTarget(ref string, variantSchemas map[string]*metaSchema) string {
+	candidates := []string{ref}
+	if !strings.ContainsAny(ref, "#/") {
+		escaped := pointerTokenEscaper.Replace(ref)
+		candidates = []string{"#/definitions/" + escaped, "#/$defs/" + escaped}
+	}
+	for _, candidate := range candidates {
+		if transitive, ok := g.transitiveRefs[candidate]; ok {
+			candidate = transitive
+		}
+		if _, ok := variantSchemas[candidate]; ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// definitionName returns the name of the definition at path, if it is one.
+func definitionName(path string) (string, bool) {
+	_, pointer := splitRef(path)
+	tokens := strings.Split(pointer, "/")
+	if len(tokens) < 3 || (tokens[len(tokens)-2] != "definitions" && tokens[len(tokens)-2] != "$defs") {
+		return "", false
+	}
+	return strings.Replace(strings.Replace(tokens[len(tokens)-1], "~1", "/", -1), "~0", "~", -1), true
+}
+
+// constString returns the value that s allows if it is a single string, given by const or by a one-value enum.
+func constString(s metaSchema) (string, bool) {
+	value := s.Const
+	if value == nil && len(s.Enum) == 1 {
+		value = s.Enum[0]
+	}
+	str, ok := value.(string)
+	return str, ok
+}
+
+// typePackage returns the import path of the package that the type at path is written to: DefinitionsPackage for
+// definitions and the types nested in them, or "" for the generated package.
+func (g *generator) typePackage(path string) string {
+	if g.DefinitionsPackage == "" {
+		return ""
+	}
+	_, pointer := splitRef(path)
+	if strings.HasPrefix(pointer, "/definitions/") || strings.HasPrefix(pointer, "/$defs/") {
+		return g.DefinitionsPackage
+	}
+	return ""
+}
+
+// typeName returns the name of the type at path as it is referred to in the package being printed, qualified by the
+// name of its package if it is in the other one.
+func (g *generator) typeName(path string) string {
+	gt := g.types[path]
+	if gt.pkg == g.printPkg {
+		return gt.Name
+	}
+	if gt.pkg == "" {
+		g.fail("definitions can't refer to %s, which isn't a definition, since the packages would import each other", gt.Name)
+	}
+	return importPathName(gt.pkg) + "." + gt.Name
+}
+
+// hasUnexportedFields returns true if the type is a struct with fields that encoding/json can't see on its own.
+func (gt goType) hasUnexportedFields() bool {
+	for _, sf := range gt.Fields {
+		if sf.Unexported {
+			return true
+		}
+	}
+	return false
+}
+
+// printUnexportedCodec writes MarshalJSON and UnmarshalJSON methods that include the type's unexported fields. Both
+// wrap the type in an alias without the methods, so the exported fields are still handled by encoding/json, and add an
+// exported field for each unexported one. UnmarshalJSON rejects unknown properties if the type is strict.
+func (g *generator) printUnexportedCodec(buf *bytes.Buffer, gt goType) {
+	fieldNames := stringset.New()
+	for _, sf := range gt.Fields {
+		fieldNames.Add(g.goName(sf))
+	}
+
+	var wrapperFields, marshalValues, unmarshalAssignments []string
+	for _, sf := range gt.Fields {
+		if !sf.Unexported {
+			continue
+		}
+		wrapperName := g.generateFieldName(sf.Name)
+		for fieldNames.Has(wrapperName) {
+			wrapperName += "_"
+		}
+		fieldNames.Add(wrapperName)
+
+		exportedField := sf
+		exportedField.Unexported = false
+		exportedField.Name = wrapperName
+		wrapperFields = append(wrapperFields, fmt.Sprintf("%s %s %s\n", wrapperName, g.typeString(sf), g.tags(exportedField)))
+		marshalValues = append(marshalValues, fmt.Sprintf("%s: v.%s,\n", wrapperName, sf.Name))
+		unmarshalAssignments = append(unmarshalAssignments, fmt.Sprintf("v.%s = aux.%s\n", sf.Name, wrapperName))
+	}
+
+	buf.WriteString(fmt.Sprintf("// MarshalJSON encodes %s, including its unexported fields.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (v %s) MarshalJSON() ([]byte, error) {\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("type alias %s\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("return json.Marshal(struct {\nalias\n%s}{\nalias: alias(v),\n%s})\n}\n\n", strings.Join(wrapperFields, ""), strings.Join(marshalValues, "")))
+
+	buf.WriteString(fmt.Sprintf("// UnmarshalJSON decodes %s, including its unexported fields.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (v *%s) UnmarshalJSON(data []byte) error {\n", gt.Name))
+	if g.isStrict(gt) {
+		g.printUnknownPropertyCheck(buf, gt)
+	}
+	buf.WriteString(fmt.Sprintf("type alias %s\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("aux := struct {\n*alias\n%s}{\nalias: (*alias)(v),\n}\n", strings.Join(wrapperFields, "")))
+	buf.WriteString("if err := json.Unmarshal(data, &aux); err != nil {\nreturn err\n}\n")
+	buf.WriteString(fmt.Sprintf("%sreturn nil\n}\n", strings.Join(unmarshalAssignments, "")))
+}
+
+// overflowField returns the field that holds the properties the type's schema doesn't list, if it has one.
+func (gt goType) overflowField() (structField, bool) {
+	for _, sf := range gt.Fields {
+		if sf.Overflow {
+			return sf, true
+		}
+	}
+	return structField{}, false
+}
+
+// printOverflowCodec writes JSON methods that route the properties the type doesn't have fields for to and from its
+// overflow field. Properties with fields take precedence when marshalling, and nil nullable fields are written as null
+// if NullMethods is set.
+func (g *generator) printOverflowCodec(buf *bytes.Buffer, gt goType, overflow structField) {
+	valueType := g.typeString(structField{TypePrefix: strings.TrimPrefix(overflow.TypePrefix, "map[string]"), TypeRef: overflow.TypeRef, Required: true})
+
+	buf.WriteString(fmt.Sprintf("// MarshalJSON encodes %s, including the properties in %s.\n", gt.Name, overflow.Name))
+	buf.WriteString(fmt.Sprintf("func (v %s) MarshalJSON() ([]byte, error) {\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("type alias %s\n", gt.Name))
+	nullFields := g.nullFields(gt)
+	if len(nullFields) > 0 {
+		// the null properties are added even if there are no others
+		buf.WriteString("data, err := json.Marshal(alias(v))\nif err != nil {\nreturn nil, err\n}\n")
+	} else {
+		buf.WriteString(fmt.Sprintf("data, err := json.Marshal(alias(v))\nif err != nil || len(v.%s) == 0 {\nreturn data, err\n}\n", overflow.Name))
+	}
+	buf.WriteString("var fields map[string]json.RawMessage\n")
+	buf.WriteString("if err := json.Unmarshal(data, &fields); err != nil {\nreturn nil, err\n}\n")
+	buf.WriteString(fmt.Sprintf("props := make(%s, len(fields)+len(v.%s))\n", g.anyType("map[string]interface{}"), overflow.Name))
+	buf.WriteString(fmt.Sprintf("for name, value := range v.%s {\nprops[name] = value\n}\n", overflow.Name))
+	buf.WriteString("for name, value := range fields {\nprops[name] = value\n}\n")
+	g.printNullProps(buf, nullFields)
+	buf.WriteString("return json.Marshal(props)\n}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// UnmarshalJSON decodes %s, keeping the properties it doesn't have fields for in %s.\n", gt.Name, overflow.Name))
+	buf.WriteString(fmt.Sprintf("func (v *%s) UnmarshalJSON(data []byte) error {\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("type alias %s\n", gt.Name))
+	buf.WriteString("if err := json.Unmarshal(data, (*alias)(v)); err != nil {\nreturn err\n}\n")
+	buf.WriteString("var props map[string]json.RawMessage\n")
+	buf.WriteString("if err := json.Unmarshal(data, &props); err != nil {\nreturn err\n}\n")
+	buf.WriteString(fmt.Sprintf("v.%s = nil\nfor name, raw := range props {\n", overflow.Name))
+	if names := g.propertyNames(gt); len(names) > 0 {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = strconv.Quote(name)
+		}
+		buf.WriteString(fmt.Sprintf("switch name {\ncase %s:\ncontinue\n}\n", strings.Join(quoted, ", ")))
+	}
+	buf.WriteString(fmt.Sprintf("var value %s\n", valueType))
+	buf.WriteString("if err := json.Unmarshal(raw, &value); err != nil {\nreturn err\n}\n")
+	buf.WriteString(fmt.Sprintf("if v.%s == nil {\nv.%s = make(map[string]%s)\n}\n", overflow.Name, overflow.Name, valueType))
+	buf.WriteString(fmt.Sprintf("v.%s[name] = value\n}\nreturn nil\n}\n", overflow.Name))
+}
+
+// nullFields returns the fields of a struct type that NullMethods writes as null when they are nil: the nullable ones
+// that can be nil and would otherwise be left out by omitempty.
+func (g *generator) nullFields(gt goType) []structField {
+	if !g.NullMethods || gt.TypePrefix != typeStruct || g.NoOmitEmpty {
+		return nil
+	}
+	var fields []structField
+	for _, sf := range gt.Fields {
+		if _, ok := sf.ExtraTags["json"]; ok || !sf.Nullable || sf.Required || sf.Embedded || sf.Overflow || sf.Unexported {
+			continue
+		}
+		typePrefix := g.basePrefix(sf)
+		if g.isPointer(sf) || strings.HasPrefix(typePrefix, "[]") || strings.HasPrefix(typePrefix, "map[") ||
+			typePrefix == typeEmptyInterface || typePrefix == typeInterface {
+			fields = append(fields, sf)
+		}
+	}
+	return fields
+}
+
+// jsonKey returns the name of the field's property in JSON, which is the field name if json isn't one of the Tags.
+func (g *generator) jsonKey(sf structField) string {
+	if !stringset.New(g.Tags...).Has("json") {
+		return sf.Name
+	}
+	return g.tagKey(sf.PropertyName)
+}
+
+// printNullProps writes statements that set the properties of the nil fields among nullFields to null in props, a
+// map of the properties to marshal.
+func (g *generator) printNullProps(buf *bytes.Buffer, nullFields []structField) {
+	for _, sf := range nullFields {
+		buf.WriteString(fmt.Sprintf("if v.%s == nil {\nprops[%q] = nil\n}\n", sf.Name, g.jsonKey(sf)))
+	}
+}
+
+// printNullMarshal writes a MarshalJSON method that writes null for the nil nullable fields of the type instead of
+// leaving them out. It marshals an alias without the method, so the fields are still handled by encoding/json, and
+// adds the null properties to the result.
+func (g *generator) printNullMarshal(buf *bytes.Buffer, gt goType, nullFields []structField) {
+	buf.WriteString(fmt.Sprintf("// MarshalJSON encodes %s, writing null for its nullable fields that are nil.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (v %s) MarshalJSON() ([]byte, error) {\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("type alias %s\n", gt.Name))
+	buf.WriteString("data, err := json.Marshal(alias(v))\nif err != nil {\nreturn nil, err\n}\n")
+	buf.WriteString("var props map[string]json.RawMessage\n")
+	buf.WriteString("if err := json.Unmarshal(data, &props); err != nil {\nreturn nil, err\n}\n")
+	g.printNullProps(buf, nullFields)
+	buf.WriteString("return json.Marshal(props)\n}\n")
+}
+
+// hasJSONMethods returns true if MarshalJSON or UnmarshalJSON methods are generated for the type.
+func (g *generator) hasJSONMethods(gt goType) bool {
+	_, overflow := gt.overflowField()
+	return gt.hasUnexportedFields() || overflow || len(g.nullFields(gt)) > 0 || g.isStrict(gt) ||
+		len(g.discriminatedFields(gt)) > 0
+}
+
+// discriminatedFields returns the fields of a struct type that hold oneOf interfaces with a discriminator, which
+// encoding/json can't unmarshal into without being told which variant to make.
+func (g *generator) discriminatedFields(gt goType) []structField {
+	var fields []structField
+	for _, sf := range gt.Fields {
+		if sf.Embedded || sf.Overflow || sf.Unexported || sf.TypePrefix != "" {
+			continue
+		}
+		if len(g.types[sf.TypeRef].Registry) > 0 {
+			fields = append(fields, sf)
+		}
+	}
+	return fields
+}
+
+// formatEscaper escapes the verbs in text that is part of a format string.
+var formatEscaper = strings.NewReplacer("%", "%%")
+
+// printDiscriminatedUnmarshal writes an UnmarshalJSON method that decodes the fields holding oneOf interfaces by
+// reading the discriminator of their values and unmarshalling them into the variant that their registry makes for it.
+// The other fields are decoded into an alias without the method, so they are still handled by encoding/json. It rejects
+// unknown properties if the type is strict.
+func (g *generator) printDiscriminatedUnmarshal(buf *bytes.Buffer, gt goType, fields []structField) {
+	buf.WriteString(fmt.Sprintf("// UnmarshalJSON decodes %s, using the discriminators of its oneOf fields to pick their variants.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (v *%s) UnmarshalJSON(data []byte) error {\n", gt.Name))
+	if g.isStrict(gt) {
+		g.printUnknownPropertyCheck(buf, gt)
+	}
+	var rawFields []string
+	for _, sf := range fields {
+		jsonTag := g.tagKey(sf.PropertyName)
+		if extra, ok := sf.ExtraTags["json"]; ok {
+			jsonTag = extra
+		}
+		rawFields = append(rawFields, fmt.Sprintf("%s json.RawMessage `json:%q`\n", sf.Name, jsonTag))
+	}
+	buf.WriteString(fmt.Sprintf("type alias %s\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("aux := struct {\n*alias\n%s}{\nalias: (*alias)(v),\n}\n", strings.Join(rawFields, "")))
+	buf.WriteString("if err := json.Unmarshal(data, &aux); err != nil {\nreturn err\n}\n")
+	for _, sf := range fields {
+		oneOf := g.types[sf.TypeRef]
+		buf.WriteString(fmt.Sprintf("if len(aux.%[1]s) > 0 && string(aux.%[1]s) != \"null\" {\n", sf.Name))
+		buf.WriteString(fmt.Sprintf("var discriminator struct {\nValue string `json:%q`\n}\n", oneOf.Discriminator))
+		buf.WriteString(fmt.Sprintf("if err := json.Unmarshal(aux.%s, &discriminator); err != nil {\nreturn err\n}\n", sf.Name))
+		buf.WriteString(fmt.Sprintf("newVariant, ok := %sTypeRegistry[discriminator.Value]\n", g.typeName(sf.TypeRef)))
+		format := fmt.Sprintf("unknown %s %%q of %s", formatEscaper.Replace(oneOf.Discriminator), formatEscaper.Replace(sf.PropertyName))
+		buf.WriteString(fmt.Sprintf("if !ok {\nreturn fmt.Errorf(%s, discriminator.Value)\n}\n", strconv.Quote(format)))
+		buf.WriteString(fmt.Sprintf("variant := newVariant()\nif err := json.Unmarshal(aux.%s, variant); err != nil {\nreturn err\n}\n", sf.Name))
+		buf.WriteString(fmt.Sprintf("v.%s = variant\n}\n", sf.Name))
+	}
+	buf.WriteString("return nil\n}\n")
+}
+
+// isStrict returns true if the type should reject unknown properties when unmarshalling.
+func (g *generator) isStrict(gt goType) bool {
+	return g.StrictUnmarshal && gt.Closed && gt.TypePrefix == typeStruct
+}
+
+// printStrictUnmarshal writes an UnmarshalJSON method that rejects properties the type's schema doesn't define. It
+// decodes into an alias without the method, so the fields are still handled by encoding/json.
+func (g *generator) printStrictUnmarshal(buf *bytes.Buffer, gt goType) {
+	buf.WriteString(fmt.Sprintf("// UnmarshalJSON decodes %s, returning an error for properties it doesn't have.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (v *%s) UnmarshalJSON(data []byte) error {\n", gt.Name))
+	g.printUnknownPropertyCheck(buf, gt)
+	buf.WriteString(fmt.Sprintf("type alias %s\n", gt.Name))
+	buf.WriteString("return json.Unmarshal(data, (*alias)(v))\n}\n")
+}
+
+// printUnknownPropertyCheck writes the start of a strict UnmarshalJSON method, which returns an error if data has a
+// property that isn't one of the type's fields. The properties are checked directly because a json.Decoder with
+// DisallowUnknownFields would also reject unknown properties of nested objects whose schemas allow them.
+func (g *generator) printUnknownPropertyCheck(buf *bytes.Buffer, gt goType) {
+	buf.WriteString("var props map[string]json.RawMessage\n")
+	buf.WriteString("if err := json.Unmarshal(data, &props); err != nil {\nreturn err\n}\n")
+	buf.WriteString("for name := range props {\n")
+	if names := g.propertyNames(gt); len(names) > 0 {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = strconv.Quote(name)
+		}
+		buf.WriteString(fmt.Sprintf("switch name {\ncase %s:\ncontinue\n}\n", strings.Join(quoted, ", ")))
+	}
+	buf.WriteString("return fmt.Errorf(\"json: unknown field %q\", name)\n}\n")
+}
+
+// propertyNames returns the sorted names of the properties of a struct type, including those of embedded types, as
+// they are in its tags.
+func (g *generator) propertyNames(gt goType) []string {
+	names := stringset.New()
+	for _, sf := range gt.Fields {
+		if sf.Embedded {
+			for _, name := range g.propertyNames(g.types[sf.TypeRef]) {
+				names.Add(name)
+			}
+		} else if !sf.Overflow {
+			names.Add(g.tagKey(sf.PropertyName))
+		}
+	}
+	return names.Sorted()
+}
+
+// printBuilder writes a builder type for a struct type. Required fields are parameters of the builder's constructor
+// and other fields are set with With methods, starting from their defaults in the schema. Build returns an error if a
+// required field that can be nil is, since that would marshal as null.
+func (g *generator) printBuilder(buf *bytes.Buffer, gt goType) {
+	exported := unicode.IsUpper([]rune(gt.Name)[0])
+	builderName := gt.Name + "Builder"
+	constructorName := g.generateIdentifier("new-"+builderName, exported)
+
+	var params, assignments []string
+	var optional, nilable structFields
+	for _, sf := range gt.Fields {
+		if !sf.Required {
+			optional = append(optional, sf)
+			continue
+		}
+		if !sf.Embedded && !sf.Nullable && g.canBeNil(sf) {
+			nilable = append(nilable, sf)
+		}
+		paramName := g.paramName(sf, gt.Name, builderName)
+		params = append(params, paramName+" "+g.typeString(sf))
+		assignments = append(assignments, fmt.Sprintf("%s: %s,\n", g.goName(sf), paramName))
+	}
+
+	buf.WriteString(fmt.Sprintf("// %s builds a %s.\n", builderName, gt.Name))
+	buf.WriteString(fmt.Sprintf("type %s struct {\nvalue %s\n}\n\n", builderName, gt.Name))
+
+	buf.WriteString(fmt.Sprintf("// %s returns a %s with the required fields of %s set and the others at their defaults.\n", constructorName, builderName, gt.Name))
+	buf.WriteString(fmt.Sprintf("func %s(%s) *%s {\n", constructorName, strings.Join(params, ", "), builderName))
+	buf.WriteString(fmt.Sprintf("v := %s{\n%s}\n", gt.Name, strings.Join(assignments, "")))
+	// the constructor from Constructors warns about the same defaults
+	g.printDefaults(buf, gt, optional, !g.Constructors)
+	buf.WriteString(fmt.Sprintf("return &%s{value: v}\n}\n", builderName))
+
+	for _, sf := range optional {
+		buf.WriteString(fmt.Sprintf("\n// With%[1]s sets %[1]s.\n", g.goName(sf)))
+		buf.WriteString(fmt.Sprintf("func (b *%s) With%s(v %s) *%s {\n", builderName, g.goName(sf), g.typeString(sf), builderName))
+		buf.WriteString(fmt.Sprintf("b.value.%s = v\nreturn b\n}\n", g.goName(sf)))
+	}
+
+	buf.WriteString(fmt.Sprintf("\n// Build returns the built %s, or an error if one of its required fields is nil.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (b *%s) Build() (%s, error) {\n", builderName, gt.Name))
+	for _, sf := range nilable {
+		buf.WriteString(fmt.Sprintf("if b.value.%s == nil {\n", g.goName(sf)))
+		buf.WriteString(fmt.Sprintf("return %s{}, errors.New(%q)\n}\n", gt.Name, fmt.Sprintf("%s: required property %q is nil", gt.Name, sf.PropertyName)))
+	}
+	buf.WriteString("return b.value, nil\n}\n")
+}
+
+// canBeNil returns true if the field is a pointer, slice, map or interface, whose zero value is nil.
+func (g *generator) canBeNil(sf structField) bool {
+	typePrefix := g.basePrefix(sf)
+	return g.isPointer(sf) || g.isSliceOrMap(sf) || typePrefix == typeEmptyInterface || typePrefix == typeInterface
+}
+
+// printConstructor prints a function that returns a value of the struct type with the fields that have a default
+// value in the schema set to it. Fields without one, or whose default can't be written as a constant, such as an
+// object, are left as zero values; the latter are logged.
+func (g *generator) printConstructor(buf *bytes.Buffer, gt goType) {
+	exported := unicode.IsUpper([]rune(gt.Name)[0])
+	constructorName := g.generateIdentifier("new-"+gt.Name, exported)
+
+	buf.WriteString(fmt.Sprintf("// %s returns a %s with the defaults from the schema set.\n", constructorName, gt.Name))
+	buf.WriteString(fmt.Sprintf("func %s() %s {\nvar v %s\n", constructorName, gt.Name, gt.Name))
+	g.printDefaults(buf, gt, gt.Fields, true)
+	buf.WriteString("return v\n}\n")
+}
+
+// printDefaults writes statements setting the fields of v, a value of the struct type, that have a default value in
+// the schema to it. Defaults that can't be written as a constant are skipped, and logged if warn is true.
+func (g *generator) printDefaults(buf *bytes.Buffer, gt goType, fields structFields, warn bool) {
+	for _, sf := range fields {
+		if sf.Embedded || sf.Default == nil {
+			continue
+		}
+		lit, ok := g.defaultLiteral(sf)
+		if !ok {
+			if warn {
+				g.warnf("Ignoring default of %s.%s: %s can't be set to %v\n", gt.Name, g.goName(sf), g.typeString(sf), sf.Default)
+			}
+			continue
+		}
+		typeStr := g.typeString(sf)
+		if strings.HasPrefix(typeStr, "*") {
+			buf.WriteString(fmt.Sprintf("v.%[1]s = new(%[2]s)\n*v.%[1]s = %[3]s\n", g.goName(sf), typeStr[1:], lit))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("v.%s = %s\n", g.goName(sf), lit))
+	}
+}
+
+// integerBounds are the ranges of the integer types that defaults are checked against, including the lower bound and
+// excluding the upper one. int is assumed to be 64 bits.
+var integerBounds = map[string][2]float64{
+	typeInt:    {math.MinInt64, 1 << 63},
+	typeInt32:  {math.MinInt32, math.MaxInt32 + 1},
+	typeInt64:  {math.MinInt64, 1 << 63},
+	typeUint32: {0, math.MaxUint32 + 1},
+	typeUint64: {0, 1 << 64},
+}
+
+// defaultLiteral returns the constant for the default value of the field, or false if its type can't hold it, or its
+// type isn't a string, number or boolean type, which are the only ones with constants.
+func (g *generator) defaultLiteral(sf structField) (string, bool) {
+	baseType := g.basePrefix(sf)
+	if baseType == g.passwordTypeName() {
+		baseType = typeString
+	}
+	if sf.TypeRef != "" && sf.TypePrefix != "" {
+		// a slice, map or pointer of a named type
+		baseType = ""
+	}
+	switch val := sf.Default.(type) {
+	case string:
+		if baseType == typeString || baseType == NumberTypeJSONNumber {
+			return strconv.Quote(val), true
+		}
+	case bool:
+		if baseType == typeBool {
+			return strconv.FormatBool(val), true
+		}
+	case float64:
+		lit := strconv.FormatFloat(val, 'f', -1, 64)
+		switch baseType {
+		case typeFloat64:
+			return lit, true
+		case NumberTypeJSONNumber:
+			return strconv.Quote(lit), true
+		case typeInt, typeInt32, typeInt64, typeUint32, typeUint64:
+			bounds := integerBounds[baseType]
+			return lit, val == math.Trunc(val) && val >= bounds[0] && val < bounds[1]
+		}
+	}
+	return "", false
+}
+
+// enumConstNames returns the names of the constants for the enum's values, falling back to the value's index when a
+// value has no usable identifier or its identifier is already taken.
+func (g *generator) enumConstNames(gt goType) []string {
+	names := make([]string, len(gt.Enum))
+	used := stringset.New()
+	for i, val := range gt.Enum {
+		var name string
+		if gt.EnumNames != nil {
+			name = g.generateIdentifier(enumIdentifierWords(gt.EnumNames[i]), true)
+		} else if num, ok := val.(float64); ok {
+			// the type name prefix makes digits valid in the identifier
+			name = strings.Replace(fmt.Sprint(num), "-", "Minus", 1)
+		} else {
+			name = g.generateIdentifier(enumIdentifierWords(fmt.Sprint(val)), true)
+		}
+		// the fallback can itself be taken by a value such as "1", which is named Value1
+		for j := 0; name == "" || used.Has(name); j++ {
+			name = fmt.Sprintf("Value%d", i)
+			if j > 0 {
+				name += fmt.Sprintf("_%d", j)
+			}
+		}
+		used.Add(name)
+		names[i] = gt.Name + name
+	}
+	return names
+}
+
+// enumIdentifierWords returns s prefixed with "value" if it starts with a digit, ignoring separators, which
+// generateIdentifier would otherwise drop, so that "1x" is named Value1x rather than X, like the value "x".
+func enumIdentifierWords(s string) string {
+	start := strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+	if start >= 0 && unicode.IsDigit([]rune(s[start:])[0]) {
+		return "value " + s
+	}
+	return s
+}
+
+func (g *generator) printEnum(buf *bytes.Buffer, gt goType) {
+	constNames := g.enumConstNames(gt)
+	buf.WriteString("\nconst (\n")
+	for i, val := range gt.Enum {
+		buf.WriteString(fmt.Sprintf("%s %s = %#v\n", constNames[i], gt.Name, val))
+	}
+	buf.WriteString(")\n")
+
+	if gt.EnumError {
+		buf.WriteString(fmt.Sprintf("\nfunc (e %s) Error() string {\nreturn string(e)\n}\n", gt.Name))
+	}
+
+	if g.EnumMarshalCheck {
+		buf.WriteString(fmt.Sprintf("\n// MarshalJSON returns an error if e is not one of the %s constants.\n", gt.Name))
+		buf.WriteString(fmt.Sprintf("func (e %s) MarshalJSON() ([]byte, error) {\n", gt.Name))
+		buf.WriteString(fmt.Sprintf("switch e {\ncase %s:\nreturn json.Marshal(%s(e))\n}\n", strings.Join(constNames, ", "), gt.TypePrefix))
+		buf.WriteString(fmt.Sprintf("return nil, fmt.Errorf(\"invalid %s value %%#v\", %s(e))\n}\n", gt.Name, gt.TypePrefix))
+	}
+
+	if g.EnumValidation {
+		g.printEnumValidation(buf, gt, constNames)
+	}
+
+	if g.EnumStringer {
+		g.printEnumStringer(buf, gt, constNames)
+	}
+}
+
+// enumVarNames returns the names of the enum values of s from x-enum-varnames, or nil if it doesn't have a name for
+// each of them.
+func (g *generator) enumVarNames(s *metaSchema, path string) []string {
+	if len(s.EnumVarNames) == 0 {
+		return nil
+	}
+	if len(s.EnumVarNames) != len(s.Enum) {
+		g.warnf("Ignoring x-enum-varnames at %s: it has %d names for %d values\n", path, len(s.EnumVarNames), len(s.Enum))
+		return nil
+	}
+	names := make([]string, 0, len(s.EnumVarNames))
+	for i, name := range s.EnumVarNames {
+		if s.Enum[i] != nil {
+			// null has no constant to name
+			names = append(names, string(name))
+		}
+	}
+	return names
+}
+
+// printEnumStringer writes a String method that returns the name of each constant's value, or the value itself if
+// the schema doesn't name them. Other values are written like Color(7).
+func (g *generator) printEnumStringer(buf *bytes.Buffer, gt goType, constNames []string) {
+	buf.WriteString(fmt.Sprintf("\n// String returns the name of e if it is one of the %s constants.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (e %s) String() string {\nswitch e {\n", gt.Name))
+	for i, val := range gt.Enum {
+		label := fmt.Sprint(val)
+		if gt.EnumNames != nil {
+			label = gt.EnumNames[i]
+		}
+		buf.WriteString(fmt.Sprintf("case %s:\nreturn %q\n", constNames[i], label))
+	}
+	if gt.TypePrefix == typeString {
+		buf.WriteString("}\nreturn string(e)\n}\n")
+		return
+	}
+	buf.WriteString(fmt.Sprintf("}\nreturn fmt.Sprintf(\"%s(%%d)\", %s(e))\n}\n", gt.Name, gt.TypePrefix))
+}
+
+// printEnumValidation writes an IsValid method that reports whether a value is one of the enum's constants, and a
+// function returning all of them, named like allStatusValues, or AllStatusValues for an exported type.
+func (g *generator) printEnumValidation(buf *bytes.Buffer, gt goType, constNames []string) {
+	buf.WriteString(fmt.Sprintf("\n// IsValid returns true if e is one of the %s constants.\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("func (e %s) IsValid() bool {\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("switch e {\ncase %s:\nreturn true\n}\nreturn false\n}\n", strings.Join(constNames, ", ")))
+
+	funcName := "all" + g.generateIdentifier(gt.Name, true) + "Values"
+	if unicode.IsUpper([]rune(gt.Name)[0]) {
+		funcName = "A" + funcName[1:]
+	}
+	buf.WriteString(fmt.Sprintf("\n// %s returns the %s constants.\n", funcName, gt.Name))
+	buf.WriteString(fmt.Sprintf("func %s() []%s {\n", funcName, gt.Name))
+	buf.WriteString(fmt.Sprintf("return []%s{%s}\n}\n", gt.Name, strings.Join(constNames, ", ")))
+}
+
+// sqlScanCases are the cases of a type switch in Scan that convert a driver value to each scalar type. The scanned
+// value is assigned to s. uint64 isn't a column type, since drivers only take integers up to the maximum int64.
+var sqlScanCases = map[string]string{
+	typeString:  "case string:\ns = src\ncase []byte:\ns = string(src)\n",
+	typeInt:     "case int64:\ns = int(src)\n",
+	typeInt32:   "case int64:\ns = int32(src)\n",
+	typeInt64:   "case int64:\ns = src\n",
+	typeUint32:  "case int64:\ns = uint32(src)\n",
+	typeFloat64: "case float64:\ns = src\ncase int64:\ns = float64(src)\n",
+	typeBool:    "case bool:\ns = src\n",
+}
+
+// sqlValueTypes are the driver value types for each scalar type.
+var sqlValueTypes = map[string]string{
+	typeString:  typeString,
+	typeInt:     typeInt64,
+	typeInt32:   typeInt64,
+	typeInt64:   typeInt64,
+	typeUint32:  typeInt64,
+	typeFloat64: typeFloat64,
+	typeBool:    typeBool,
+}
+
+// isSQLScalar returns true if the type is a named scalar type that can be used as a database column.
+func (gt goType) isSQLScalar() bool {
+	_, ok := sqlScanCases[gt.TypePrefix]
+	return ok && gt.TypeRef == ""
+}
+
+// printSQLMethods writes Scan and Value methods so that the type implements sql.Scanner and driver.Valuer. For enums,
+// Scan returns an error if the value is not one of the constants.
+func (g *generator) printSQLMethods(buf *bytes.Buffer, gt goType) {
+	buf.WriteString("// Scan implements sql.Scanner.\n")
+	buf.WriteString(fmt.Sprintf("func (v *%s) Scan(src %s) error {\n", gt.Name, g.anyType(typeEmptyInterface)))
+	buf.WriteString(fmt.Sprintf("var s %s\nswitch src := src.(type) {\n%s", gt.TypePrefix, sqlScanCases[gt.TypePrefix]))
+	buf.WriteString(fmt.Sprintf("default:\nreturn fmt.Errorf(\"can't scan %%T into %s\", src)\n}\n", gt.Name))
+	if len(gt.Enum) > 0 {
+		// check the value before assigning it, so an invalid one leaves v untouched
+		buf.WriteString(fmt.Sprintf("switch %s(s) {\ncase %s:\ndefault:\n", gt.Name, strings.Join(g.enumConstNames(gt), ", ")))
+		buf.WriteString(fmt.Sprintf("return fmt.Errorf(\"invalid %s value %%#v\", s)\n}\n", gt.Name))
+	}
+	buf.WriteString(fmt.Sprintf("*v = %s(s)\nreturn nil\n", gt.Name))
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// Value implements driver.Valuer.\n")
+	buf.WriteString(fmt.Sprintf("func (v %s) Value() (driver.Value, error) {\n", gt.Name))
+	buf.WriteString(fmt.Sprintf("return %s(v), nil\n}\n", sqlValueTypes[gt.TypePrefix]))
+}
+
+type goTypes []goType
+
+func (t goTypes) Len() int {
+	return len(t)
+}
+
+func (t goTypes) Less(i, j int) bool {
+	return t[i].Name < t[j].Name
+}
+
+func (t goTypes) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+}
+
+const (
+	typeString              = "string"
+	typeInteger             = "integer"
+	typeInt                 = "int"
+	typeInt32               = "int32"
+	typeInt64               = "int64"
+	typeUint32              = "uint32"
+	typeUint64              = "uint64"
+	typeNumber              = "number"
+	typeFloat64             = "float64"
+	typeBoolean             = "boolean"
+	typeBool                = "bool"
+	typeNull                = "null"
+	typeObject              = "object"
+	typeArray               = "array"
+	typeEmptyInterface      = "interface{}"
+	typeEmptyInterfaceSlice = "[]interface{}"
+	typeTime                = "time.Time"
+	typeStruct              = "struct"
+	typeEmptyStruct         = "struct{}"
+	typeInterface           = "interface"
+)
+
+var typeStrings = map[string]string{
+	typeString:  typeString,
+	typeInteger: typeInt,
+	typeNumber:  typeFloat64,
+	typeBoolean: typeBool,
+	typeNull:    typeEmptyInterface, // nil is not a type; a null value can only be held by an interface
+	typeObject:  typeObject,
+	typeArray:   typeArray,
+}
+
+// integerFormats are the Go types for the formats of integers that fix their size.
+var integerFormats = map[string]string{
+	typeInt32:  typeInt32,
+	typeInt64:  typeInt64,
+	typeUint32: typeUint32,
+	typeUint64: typeUint64,
+}
+
+func (g *generator) getTypeString(jsonType, format string) string {
+	if goType, ok := g.typeMappings[format]; ok && jsonType != typeObject && jsonType != typeArray {
+		return goType
+	}
+	if format == "date-time" {
+		return typeTime
+	}
+	if ts, ok := integerFormats[format]; ok && jsonType == typeInteger {
+		return ts
+	}
+	if jsonType == typeNumber && g.NumberType == NumberTypeJSONNumber {
+		return NumberTypeJSONNumber
+	}
+	if goType, ok := g.formatGoTypes[format]; ok && jsonType == typeString {
+		return goType
+	}
+	if _, ok := civilFormats[format]; ok && jsonType == typeString {
+		g.civilTypes.Add(format)
+		return g.civilTypeName(format)
+	}
+	if format == "password" && jsonType == typeString && g.RedactPasswords {
+		g.needPasswordType = true
+		return g.passwordTypeName()
+	}
+
+	if ts, ok := typeStrings[jsonType]; ok {
+		return ts
+	}
+	return typeEmptyInterface
+}
+
+// copied from golint (https://github.com/golang/lint/blob/4946cea8b6efd778dc31dc2dbeb919535e1b7529/lint.go#L701)
+var commonInitialisms = stringset.New(
+	"API",
+	"ASCII",
+	"CPU",
+	"CSS",
+	"DNS",
+	"EOF",
+	"GUID",
+	"HTML",
+	"HTTP",
+	"HTTPS",
+	"ID",
+	"IP",
+	"JSON",
+	"LHS",
+	"QPS",
+	"RAM",
+	"RHS",
+	"RPC",
+	"SLA",
+	"SMTP",
+	"SQL",
+	"SSH",
+	"TCP",
+	"TLS",
+	"TTL",
+	"UDP",
+	"UI",
+	"UID",
+	"UUID",
+	"URI",
+	"URL",
+	"UTF8",
+	"VM",
+	"XML",
+	"XSRF",
+	"XSS",
+)
+
+// goKeywords are the identifiers reserved by Go, which unexported names can collide with.
+var goKeywords = stringset.New(
+	"break",
+	"case",
+	"chan",
+	"const",
+	"continue",
+	"default",
+	"defer",
+	"else",
+	"fallthrough",
+	"for",
+	"func",
+	"go",
+	"goto",
+	"if",
+	"import",
+	"interface",
+	"map",
+	"package",
+	"range",
+	"return",
+	"select",
+	"struct",
+	"switch",
+	"type",
+	"var",
+)
+
+func dashedToWords(s string) string {
+	return regexp.MustCompile("-|_").ReplaceAllString(s, " ")
+}
+
+func camelCaseToWords(s string) string {
+	return regexp.MustCompile(`([\p{Ll}\p{N}])(\p{Lu})`).ReplaceAllString(s, "$1 $2")
+}
+
+// tagKey returns the key in struct tags for the property: its name, converted to snake_case or camelCase if
+// JSONTagCase says so. The words are split as they are for identifiers, but initialisms aren't kept in uppercase.
+func (g *generator) tagKey(propName string) string {
+	if g.JSONTagCase != JSONTagCaseSnake && g.JSONTagCase != JSONTagCaseCamel {
+		return propName
+	}
+	words := strings.Fields(camelCaseToWords(dashedToWords(propName)))
+	if len(words) == 0 {
+		return propName
+	}
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 && g.JSONTagCase == JSONTagCaseCamel {
+			word = strings.Title(word)
+		}
+		words[i] = word
+	}
+	if g.JSONTagCase == JSONTagCaseSnake {
+		return strings.Join(words, "_")
+	}
+	return strings.Join(words, "")
+}
+
+func (g *generator) getExportedIdentifierPart(part string) string {
+	upperedPart := strings.ToUpper(part)
+	if g.initialisms.Has(upperedPart) {
+		return upperedPart
+	}
+	return strings.Title(strings.ToLower(part))
+}
+
+func (g *generator) generateIdentifier(origName string, exported bool) string {
+	spacedName := camelCaseToWords(dashedToWords(origName))
+	titledName := strings.Title(spacedName)
+	nameParts := strings.Split(titledName, " ")
+	for i, part := range nameParts {
+		nameParts[i] = g.getExportedIdentifierPart(part)
+	}
+	if !exported {
+		// leading separators leave empty parts, so lowercase the first non-empty one
+		for i, part := range nameParts {
+			if part != "" {
+				nameParts[i] = strings.ToLower(part)
+				break
+			}
+		}
+	}
+	rawName := strings.Join(nameParts, "")
+
+	// make sure we build a valid identifier
+	buf := &bytes.Buffer{}
+	for pos, char := range rawName {
+		if unicode.IsLetter(char) || char == '_' || (unicode.IsDigit(char) && pos > 0) {
+			buf.WriteRune(char)
+		}
+	}
+
+	name := buf.String()
+	if goKeywords.Has(name) {
+		name += "_"
+	}
+	return name
+}
+
+// exportTypes returns true if generated types and helpers are exported, which they are outside package main unless
+// Unexported is set.
+func (g *generator) exportTypes() bool {
+	return (g.PackageName != "main" || g.DefinitionsPackage != "") && !g.Unexported
+}
+
+func (g *generator) generateTypeName(origName string) string {
+	var name string
+	switch {
+	case g.Unexported && g.TypeNamesPrefix != "":
+		// the prefix starts the name, so it is the part that's unexported
+		name = g.generateIdentifier(g.TypeNamesPrefix, false) + g.generateIdentifier(origName, true)
+	case g.exportTypes() || g.TypeNamesPrefix != "":
+		name = g.TypeNamesPrefix + g.generateIdentifier(origName, true)
+	default:
+		name = g.generateIdentifier(origName, false)
+	}
+
+	// avoid names such as error or map that shadow predeclared identifiers or are keywords; keywords already carry
+	// the underscore generateIdentifier adds, which the suffix replaces. Exported names such as Len or Error can't
+	// shadow anything, so they are kept.
+	if g.AvoidBuiltinShadow {
+		if base := strings.TrimSuffix(name, "_"); token.IsKeyword(base) {
+			name = base + "Type"
+		} else if gotypes.Universe.Lookup(name) != nil {
+			name += "Type"
+		}
+	}
+	if g.MaxNameLength > 0 {
+		name = abbreviateName(name, g.MaxNameLength)
+	}
+	return name
+}
+
+// nameHashLength is the length of the hash that ends a type name abbreviated by abbreviateName.
+const nameHashLength = 6
+
+// abbreviateName shortens name to maxLength, if it is longer, by replacing its end with a hash of the whole name. Names
+// that only differ after the cut stay different, and a name is always abbreviated the same way, so dedupeTypes still
+// disambiguates abbreviated names that are the same by prefixing their parents' names, which changes the hash.
+func abbreviateName(name string, maxLength int) string {
+	runes := []rune(name)
+	if len(runes) <= maxLength {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	hash := fmt.Sprintf("%08X", h.Sum32())[:nameHashLength]
+	return string(runes[:maxLength-nameHashLength]) + hash
+}
+
+func (g *generator) generateFieldName(origName string) string {
+	return g.generateIdentifier(origName, true)
+}
+
+func getTypeSchema(typeInterface interface{}) *metaSchema {
+	typeSchemaJSON, _ := json.Marshal(typeInterface)
+	var typeSchema metaSchema
+	json.Unmarshal(typeSchemaJSON, &typeSchema)
+	return &typeSchema
+}
+
+func getTypeSchemas(typeInterface interface{}) map[string]*metaSchema {
+	typeSchemasJSON, _ := json.Marshal(typeInterface)
+	var typeSchemas map[string]*metaSchema
+	json.Unmarshal(typeSchemasJSON, &typeSchemas)
+	return typeSchemas
+}
+
+func singularize(plural string) string {
+	singular := inflector.Singularize(plural)
+	if singular == plural {
+		singular += "Item"
+	}
+	return singular
+}
+
+// isErrorEnum returns true if s is a string enum that should generate an error type, either because --enum-errors is set
+// and the type's name contains "error" or because the schema sets x-go-error.
+func (g *generator) isErrorEnum(s *metaSchema, name string) bool {
+	if !g.EnumErrors || len(s.Enum) == 0 {
+		return false
+	}
+	for _, val := range nonNullEnum(s.Enum) {
+		if _, ok := val.(string); !ok {
+			return false
+		}
+	}
+	return s.GoError || strings.Contains(strings.ToLower(name), "error")
+}
+
+// isMixedEnum returns true if the values of enum have more than one JSON type, so they can't share a typed constant
+// set.
+func isMixedEnum(enum []interface{}) bool {
+	values := nonNullEnum(enum)
+	for i := 1; i < len(values); i++ {
+		if fmt.Sprintf("%T", values[i]) != fmt.Sprintf("%T", values[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonNullEnum returns the values of enum other than null, which a nullable field holds as nil instead of a constant.
+func nonNullEnum(enum []interface{}) []interface{} {
+	values := make([]interface{}, 0, len(enum))
+	for _, val := range enum {
+		if val != nil {
+			values = append(values, val)
+		}
+	}
+	return values
+}
+
+// enumHasNull returns true if null is one of the values of enum, so the type or field holding it is nullable.
+func enumHasNull(enum []interface{}) bool {
+	return len(nonNullEnum(enum)) < len(enum)
+}
+
+// enumValuesComment returns a comment listing the allowed values of enum as JSON.
+func enumValuesComment(enum []interface{}) string {
+	vals := make([]string, len(enum))
+	for i, val := range enum {
+		valJSON, _ := json.Marshal(val)
+		vals[i] = string(valJSON)
+	}
+	return "Allowed values: " + strings.Join(vals, ", ") + "."
+}
+
+// constJSONType returns the narrowest JSON type of the const value val, so that integral numbers are integers.
+func constJSONType(val interface{}) string {
+	switch val := val.(type) {
+	case string:
+		return typeString
+	case bool:
+		return typeBoolean
+	case float64:
+		if val == math.Trunc(val) {
+			return typeInteger
+		}
+		return typeNumber
+	case map[string]interface{}:
+		return typeObject
+	case []interface{}:
+		return typeArray
+	}
+	return typeNull
+}
+
+// constValueComment returns a comment noting the fixed value of a const.
+func constValueComment(val interface{}) string {
+	valJSON, _ := json.Marshal(val)
+	return "must be " + string(valJSON)
+}
+
+// collapsesInline returns true if s, the schema at path, is an object with properties that would get a type of its own,
+// but is nested deeper than MaxInlineDepth, so it is a map[string]interface{} instead.
+func (g *generator) collapsesInline(s *metaSchema, path string) bool {
+	return g.MaxInlineDepth > 0 && s.Ref == "" && len(s.Properties) > 0 && inlineDepth(path) > g.MaxInlineDepth
+}
+
+// inlineDepth returns how deeply the schema at path is nested in the root schema or the definition containing it,
+// counting each property, array items and additional properties schema on the way. For example,
+// #/definitions/user/properties/address/properties/street has a depth of 2.
+func inlineDepth(path string) int {
+	depth := 0
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		switch segments[i] {
+		case "definitions", "$defs":
+			// a definition is named, so nesting starts over
+			depth = 0
+			i++
+		case "properties", "patternProperties":
+			depth++
+			i++
+		case "items", "prefixItems":
+			depth++
+			if i+1 < len(segments) {
+				if _, err := strconv.Atoi(segments[i+1]); err == nil {
+					i++
+				}
+			}
+		case "additionalProperties":
+			depth++
+		case "allOf", "oneOf":
+			// members are merged into or implement the type containing them
+			i++
+		}
+	}
+	return depth
+}
+
+// arrayItems returns the schemas of the items of the array s and the keyword they are under. The prefixItems of a
+// draft 2020-12 tuple take the place of the array of schemas in items that earlier drafts use.
+func arrayItems(s *metaSchema) (items interface{}, keyword string) {
+	if len(s.PrefixItems) > 0 {
+		return s.PrefixItems, "prefixItems"
+	}
+	return s.Items, "items"
+}
+
+// tupleComment returns a comment listing the types of the items of a tuple by position: their JSON types, or the
+// names of the definitions they refer to.
+func tupleComment(items []interface{}) string {
+	if len(items) == 0 {
+		return ""
+	}
+	types := make([]string, len(items))
+	for i, item := range items {
+		itemSchema := getTypeSchema(item)
+		switch {
+		case itemSchema.Ref != "":
+			types[i] = itemSchema.Ref[strings.LastIndex(itemSchema.Ref, "/")+1:]
+		case schemaJSONType(itemSchema) != "":
+			types[i] = schemaJSONType(itemSchema)
+		default:
+			types[i] = "any"
+		}
+	}
+	return "tuple of " + strings.Join(types, ", ")
+}
+
+// noItemsComment notes that an array whose items schema is false can't have any items.
+const noItemsComment = "must be empty"
+
+// deprecatedNotice is the deprecation notice of a deprecated schema, which follows its description.
+const deprecatedNotice = "Deprecated: the schema marks this as deprecated."
+
+// deprecatedComment returns the deprecation notice for the field of a property with schema s, or "" if it isn't
+// deprecated. The description, which usually says what to use instead, is already the start of the field's comment.
+func deprecatedComment(s *metaSchema) string {
+	if !s.Deprecated {
+		return ""
+	}
+	return deprecatedNotice
+}
+
+// Values of structField.Access.
+const (
+	accessRead  = "read"
+	accessWrite = "write"
+)
+
+// propertyAccess returns accessRead if the property with schema s is read-only, accessWrite if it is write-only, or ""
+// if it is neither, or both, which makes no sense.
+func propertyAccess(s *metaSchema) string {
+	switch {
+	case s.ReadOnly && !s.WriteOnly:
+		return accessRead
+	case s.WriteOnly && !s.ReadOnly:
+		return accessWrite
+	}
+	return ""
+}
+
+// exampleComment returns a comment showing the first of the example values, or "" if there are none.
+func exampleComment(examples []interface{}) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	valJSON, _ := json.Marshal(examples[0])
+	return "Example: " + string(valJSON)
+}
+
+// dependentRequiredComment returns a comment with a sentence for each property that requires others when present,
+// from dependentRequired and the property form of dependencies, or "" if there are none.
+func dependentRequiredComment(s *metaSchema) string {
+	required := make(map[string][]string)
+	for propName, dep := range s.Dependencies {
+		props, ok := dep.([]interface{})
+		if !ok {
+			// schema dependencies are reported as unsupported
+			continue
+		}
+		for _, prop := range props {
+			if propStr, ok := prop.(string); ok {
+				required[propName] = append(required[propName], propStr)
+			}
+		}
+	}
+	for propName, props := range s.DependentRequired {
+		for _, prop := range props {
+			required[propName] = append(required[propName], string(prop))
+		}
+	}
+	propNames, _ := stringset.FromMapKeys(required)
+	var lines []string
+	for _, propName := range propNames.Sorted() {
+		props := required[propName]
+		quoted := make([]string, len(props))
+		for i, prop := range props {
+			quoted[i] = strconv.Quote(prop)
+		}
+		list, verb := quoted[0], "is"
+		if len(quoted) > 1 {
+			list, verb = strings.Join(quoted[:len(quoted)-1], ", ")+" and "+quoted[len(quoted)-1], "are"
+		}
+		lines = append(lines, fmt.Sprintf("If %q is present, %s %s required.", propName, list, verb))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonString returns true if sf, the field of the property s at path, is a number or boolean encoded as a JSON
+// string, which s marks with x-go-json-string or the format "string". encoding/json only quotes numbers and booleans,
+// so x-go-json-string is ignored with a warning on fields of other types.
+func (g *generator) jsonString(s *metaSchema, sf structField, path string) bool {
+	if !s.GoJSONString && s.Format != "string" {
+		return false
+	}
+	ts := sf.TypePrefix
+	if sf.TypeRef != "" && ts == "" {
+		// an enum's named type
+		ts = g.types[sf.TypeRef].TypePrefix
+	}
+	switch ts {
+	case typeInt, typeInt32, typeInt64, typeUint32, typeUint64, typeFloat64, typeBool:
+		return true
+	}
+	if s.GoJSONString {
+		g.warnf("Ignoring x-go-json-string at %s: only numbers and booleans can be encoded as strings\n", path)
+	}
+	return false
+}
+
+// warnNonConstantEnum logs that the enum at path is ignored because a format mapped it to a type, such as time.Time,
+// that can't be used for constants. The type is kept and no constants are generated.
+func (g *generator) warnNonConstantEnum(path, ts string) {
+	g.warnf("Ignoring enum at %s: %s values can't be constants\n", path, ts)
+}
+
+// property is a property of an object schema, which may come from one of its inline allOf members.
+type property struct {
+	name   string
+	schema *metaSchema
+	path   string
+	// order is the position of the property in the schema that defines it.
+	order int
+}
+
+type propertiesByPath []property
+
+func (p propertiesByPath) Len() int {
+	return len(p)
+}
+
+func (p propertiesByPath) Less(i, j int) bool {
+	return p[i].path < p[j].path
+}
+
+func (p propertiesByPath) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// pointerToken escapes a key of a schema, such as a property name, for the JSON Pointer path of a type, so that a/b
+// becomes a~1b as it does in the $ref values that refer to the type.
+func pointerToken(key string) string {
+	return pointerTokenEscaper.Replace(key)
+}
+
+var pointerTokenEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// owner returns the path of the schema that defines the property.
+func (p property) owner() string {
+	return strings.TrimSuffix(p.path, "/properties/"+pointerToken(p.name))
+}
+
+// propertiesBySchemaOrder sorts properties by the path of the schema that defines them, so the type's own properties
+// come before those of its allOf members, and then by their position in that schema.
+type propertiesBySchemaOrder struct {
+	propertiesByPath
+}
+
+func (p propertiesBySchemaOrder) Less(i, j int) bool {
+	iOwner, jOwner := p.propertiesByPath[i].owner(), p.propertiesByPath[j].owner()
+	if iOwner != jOwner {
+		return iOwner < jOwner
+	}
+	return p.propertiesByPath[i].order < p.propertiesByPath[j].order
+}
+
+// propertyOrder returns the position of the property name in s, or the number of properties, so that it comes last,
+// if s doesn't record it.
+func propertyOrder(s *metaSchema, name string) int {
+	for i, propName := range s.GoPropertyOrder {
+		if string(propName) == name {
+			return i
+		}
+	}
+	return len(s.Properties)
+}
+
+// allOfMembers flattens the allOf members of s, including those nested in inline members, into the inline members,
+// whose properties are merged into the type, and the $ref members, whose types' fields are copied. Both are keyed
+// by path.
+func allOfMembers(s *metaSchema, path string, inline, refs map[string]*metaSchema) {
+	for index := range s.AllOf {
+		member := &s.AllOf[index]
+		memberPath := fmt.Sprintf("%s/allOf/%d", path, index)
+		if member.Ref != "" {
+			refs[memberPath] = member
+			continue
+		}
+		inline[memberPath] = member
+		allOfMembers(member, memberPath, inline, refs)
+	}
+}
+
+func sortedSchemaPaths(schemas map[string]*metaSchema) []string {
+	paths, _ := stringset.FromMapKeys(schemas)
+	return paths.Sorted()
+}
+
+// mergeFields collapses the fields for each property, which can come from several allOf members, into one that is
+// required if any of them is. An untyped field, such as one that only adds a description, takes the type of the
+// others. Fields with different types become interface{}, and a warning is logged.
+func (g *generator) mergeFields(fields structFields, path string) structFields {
+	merged := make(structFields, 0, len(fields))
+	indexes := make(map[string]int)
+	conflicts := stringset.New()
+	for _, sf := range fields {
+		i, ok := indexes[sf.PropertyName]
+		if sf.Embedded || !ok {
+			if !sf.Embedded {
+				indexes[sf.PropertyName] = len(merged)
+			}
+			merged = append(merged, sf)
+			continue
+		}
+
+		prev := &merged[i]
+		required := prev.Required || sf.Required
+		switch {
+		case conflicts.Has(sf.PropertyName), sf.isUntyped():
+		case prev.isUntyped():
+			*prev = sf
+		case prev.TypePrefix != sf.TypePrefix || prev.TypeRef != sf.TypeRef || prev.Nullable != sf.Nullable:
+			g.warnf("Conflicting types for property %q in allOf at %s; using interface{}\n", sf.PropertyName, path)
+			conflicts.Add(sf.PropertyName)
+			prev.TypePrefix, prev.TypeRef, prev.Nullable, prev.PtrForOmit = typeEmptyInterface, "", false, false
+		}
+		prev.Required = required
+	}
+	return merged
+}
+
+// dedupeFieldNames renames fields whose names collide with an earlier field's, such as the fields for the properties
+// name and name$, by appending a number to them. Their tags still use the property names.
+func (g *generator) dedupeFieldNames(fields structFields) structFields {
+	taken := stringset.New()
+	for _, sf := range fields {
+		if sf.Embedded {
+			taken.Add(g.goName(sf))
+		}
+	}
+	for i, sf := range fields {
+		if sf.Embedded {
+			continue
+		}
+		name := sf.Name
+		for n := 2; taken.Has(name); n++ {
+			name = fmt.Sprintf("%s%d", sf.Name, n)
+		}
+		if name != sf.Name {
+			g.warnf("Renaming field %s for property %q to %s to avoid a duplicate\n", sf.Name, sf.PropertyName, name)
+		}
+		fields[i].Name = name
+		taken.Add(name)
+	}
+	return fields
+}
+
+func parseAdditionalProperties(ap interface{}) (hasAddl bool, addlSchema *metaSchema) {
+	switch ap := ap.(type) {
+	case bool:
+		return ap, nil
+	case map[string]interface{}:
+		return true, getTypeSchema(ap)
+	default:
+		return
+	}
+}
+
+// patternValues returns the first of the patterns of patternProperties, in sorted order, and the schema of the values
+// whose keys match it. The schema is nil if the patterns' schemas differ, since a map can only have one value type.
+func patternValues(patterns map[string]metaSchema) (pattern string, valueSchema *metaSchema) {
+	sortedPatterns := sortedPatterns(patterns)
+	firstSchema := patterns[sortedPatterns[0]]
+	firstJSON, _ := json.Marshal(firstSchema)
+	for _, other := range sortedPatterns[1:] {
+		patternJSON, _ := json.Marshal(patterns[other])
+		if !bytes.Equal(patternJSON, firstJSON) {
+			return sortedPatterns[0], nil
+		}
+	}
+	return sortedPatterns[0], &firstSchema
+}
+
+// patternsComment returns a comment documenting the patterns that the keys of a map from patternProperties match.
+func patternsComment(patterns map[string]metaSchema) string {
+	return "Keys match " + strings.Join(sortedPatterns(patterns), " or ") + "."
+}
+
+func sortedPatterns(patterns map[string]metaSchema) []string {
+	keys, _ := stringset.FromMapKeys(patterns)
+	return keys.Sorted()
+}
+
+// isClosedObject returns true if s explicitly disallows properties it doesn't define with additionalProperties false,
+// as opposed to leaving additionalProperties unspecified.
+func isClosedObject(s *metaSchema) bool {
+	closed, ok := s.AdditionalProperties.(bool)
+	return ok && !closed && len(s.PatternProperties) == 0
+}
+
+type deferredType struct {
+	schema     *metaSchema
+	name       string
+	desc       string
+	parentPath string
+}
+
+type stringSetMap map[string]stringset.StringSet
+
+func (m stringSetMap) addTo(set, val string) {
+	if m[set] == nil {
+		m[set] = stringset.New()
+	}
+	m[set].Add(val)
+}
+
+func (m stringSetMap) removeFrom(set, val string) {
+	if m[set] == nil {
+		return
+	}
+	m[set].Remove(val)
+}
+
+func (m stringSetMap) existsIn(set, val string) bool {
+	if m[set] == nil {
+		return false
+	}
+	return m[set].Has(val)
+}
+
+func (m stringSetMap) delete(set string) {
+	delete(m, set)
+}
+
+func (m stringSetMap) has(set string) bool {
+	_, ok := m[set]
+	return ok
+}
+
+func (g *generator) processType(s *metaSchema, pName, pDesc, path, parentPath string) (typeRef string) {
+	if len(s.Definitions) > 0 || len(s.Defs) > 0 {
+		g.parseDefs(s, path)
+	}
+
+	var gt goType
+
+	if s.Ref != "" {
+		ref, ok := g.transitiveRefs[s.Ref]
+		if !ok {
+			ref = s.Ref
+		}
+		g.processExternalRef(ref, parentPath)
+		g.processLocalRef(ref, parentPath)
+		if _, ok := g.types[ref]; ok {
+			g.transitiveRefs[path] = ref
+			return ref
+		}
+		g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+		return ""
+	}
+
+	gt.parentPath = parentPath
+
+	if path == "#" {
+		gt.origTypeName = g.RootTypeName
+		gt.Name = g.RootTypeName
+	} else {
+		gt.origTypeName = s.Title
+		if gt.origTypeName == "" {
+			gt.origTypeName = pName
+		}
+
+		if gt.Name = g.generateTypeName(gt.origTypeName); gt.Name == "" {
+			g.fail("can't generate type without name at %s", path)
+		}
+	}
+
+	typeRef = path
+
+	gt.Comment = s.Description
+	if gt.Comment == "" {
+		gt.Comment = pDesc
+	}
+
+	required := stringset.New()
+	for _, req := range s.Required {
+		required.Add(string(req))
+	}
+
+	defer func() {
+		gt.pkg = g.typePackage(path)
+		g.types[path] = gt
+		g.typesByName.addTo(gt.Name, path)
+	}()
+
+	var jsonType string
+	switch schemaType := s.Type.(type) {
+	case []interface{}:
+		var nullable bool
+		jsonType, nullable = parseTypeArray(schemaType)
+		if nullable {
+			gt.Nullable = true
+		}
+	case string:
+		jsonType = schemaType
+	case nil:
+		if s.Const != nil {
+			jsonType = constJSONType(s.Const)
+		}
+	}
+	if s.Nullable || enumHasNull(s.Enum) {
+		// OpenAPI 3.0 marks nullable schemas with nullable instead of a null type, and an enum can allow null
+		gt.Nullable = true
+	}
+	if s.Const != nil {
+		if gt.Comment != "" {
+			gt.Comment += "\n"
+		}
+		gt.Comment += constValueComment(s.Const)
+	}
+	if example := exampleComment(s.Examples); example != "" {
+		if gt.Comment != "" {
+			gt.Comment += "\n"
+		}
+		gt.Comment += example
+	}
+	if deps := dependentRequiredComment(s); deps != "" {
+		if gt.Comment != "" {
+			gt.Comment += "\n"
+		}
+		gt.Comment += deps
+	}
+	if s.Deprecated {
+		if gt.Comment != "" {
+			gt.Comment += "\n\n"
+		}
+		// the description is already the first paragraph
+		gt.Comment += deprecatedNotice
+	}
+
+	if goType := g.goTypeOverride(s, path); goType != "" {
+		// the schema pins its type, so nothing is inferred from its other keywords
+		gt.TypePrefix = goType
+		return
+	}
+
+	if g.OneOfInterfaces && len(s.OneOf) > 0 && len(s.Properties) == 0 {
+		// interfaces are already nilable
+		gt.Nullable = false
+		gt.TypePrefix = typeInterface
+		variants := stringset.New()
+		variantSchemas := make(map[string]*metaSchema)
+		for index := range s.OneOf {
+			oneOfSchema := &s.OneOf[index]
+			if oneOfSchema.Type == typeNull {
+				// a nil interface holds null
+				continue
+			}
+			childPath := fmt.Sprintf("%s/oneOf/%d", path, index)
+			gotType := g.processType(oneOfSchema, fmt.Sprintf("%sVariant%d", pName, index), oneOfSchema.Description, childPath, path)
+			if gotType == "" {
+				g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+				return ""
+			}
+			if variantPrefix := g.types[gotType].TypePrefix; variantPrefix == typeEmptyInterface || variantPrefix == typeInterface {
+				g.warnf("Ignoring oneOf variant at %s: interface types can't implement %s\n", childPath, gt.Name)
+				continue
+			}
+			if !variants.Has(gotType) {
+				variants.Add(gotType)
+				gt.Variants = append(gt.Variants, gotType)
+				variantSchemas[gotType] = oneOfSchema
+			}
+		}
+		if s.Discriminator.PropertyName != "" {
+			gt.Registry = g.discriminatorValues(s, path, gt.Variants, variantSchemas)
+			gt.Discriminator = s.Discriminator.PropertyName
+		}
+		return
+	}
+
+	hasAllOf := len(s.AllOf) > 0
+	inlineMembers := make(map[string]*metaSchema)
+	refMembers := make(map[string]*metaSchema)
+	allOfMembers(s, path, inlineMembers, refMembers)
+	var allOfRefs []string
+	for _, memberPath := range sortedSchemaPaths(refMembers) {
+		member := refMembers[memberPath]
+		gotType := g.processType(member, pName, member.Description, memberPath, path)
+		if _, pending := g.deferredTypes[gotType]; gotType == "" || pending {
+			g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+			return ""
+		}
+		allOfRefs = append(allOfRefs, gotType)
+		childType := g.types[gotType]
+		// if any child is an object, the parent is an object
+		if jsonType == "" && childType.TypePrefix == typeStruct {
+			jsonType = typeObject
+		}
+		// if any child is nullable, the parent is nullable
+		if childType.Nullable {
+			gt.Nullable = true
+		}
+	}
+	for _, member := range inlineMembers {
+		for _, req := range member.Required {
+			required.Add(string(req))
+		}
+		memberTypes, _ := member.Type.([]interface{})
+		memberTypes = append(memberTypes, member.Type)
+		for _, memberType := range memberTypes {
+			if jsonType == "" && (memberType == typeObject || len(member.Properties) > 0) {
+				jsonType = typeObject
+			}
+			if memberType == typeNull {
+				gt.Nullable = true
+			}
+		}
+	}
+
+	props := getTypeSchemas(s.Properties)
+	properties := make([]property, 0, len(props))
+	for propName, propSchema := range props {
+		properties = append(properties, property{propName, propSchema, path + "/properties/" + pointerToken(propName), propertyOrder(s, propName)})
+	}
+	for memberPath, member := range inlineMembers {
+		for propName, propSchema := range getTypeSchemas(member.Properties) {
+			properties = append(properties, property{propName, propSchema, memberPath + "/properties/" + pointerToken(propName), propertyOrder(member, propName)})
+		}
+	}
+	sort.Sort(propertiesByPath(properties))
+	if g.FieldSort == FieldSortSchema {
+		sort.Stable(propertiesBySchemaOrder{properties})
+	}
+	hasProps := len(properties) > 0
+	hasAddlProps, addlPropsSchema := parseAdditionalProperties(s.AdditionalProperties)
+
+	var overflow *structField
+	ts := g.getTypeString(jsonType, s.Format)
+	switch ts {
+	case typeObject:
+		if (hasProps || hasAllOf) && !hasAddlProps {
+			gt.TypePrefix = typeStruct
+			gt.Closed = isClosedObject(s)
+		} else if hasProps || hasAllOf {
+			// the properties the schema doesn't list are kept in a map alongside the fields
+			gt.TypePrefix = typeStruct
+			overflow = &structField{
+				Name:       "AdditionalProperties",
+				TypePrefix: "map[string]interface{}",
+				Overflow:   true,
+				Comment:    "AdditionalProperties holds the properties that aren't fields.",
+			}
+			if addlPropsSchema != nil {
+				singularName := singularize(gt.origTypeName)
+				gotType := g.processType(addlPropsSchema, singularName, s.Description, path+"/additionalProperties", path)
+				if gotType == "" {
+					g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+					return ""
+				}
+				overflow.TypePrefix = "map[string]"
+				overflow.TypeRef = gotType
+			}
+		} else if !hasProps && !hasAllOf && hasAddlProps && addlPropsSchema != nil {
+			singularName := singularize(gt.origTypeName)
+			gotType := g.processType(addlPropsSchema, singularName, s.Description, path+"/additionalProperties", path)
+			if gotType == "" {
+				g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+				return ""
+			}
+			gt.TypePrefix = "map[string]"
+			gt.TypeRef = gotType
+		} else if !hasProps && !hasAllOf && addlPropsSchema == nil && len(s.PatternProperties) > 0 {
+			pattern, patternSchema := patternValues(s.PatternProperties)
+			if gt.Comment != "" {
+				gt.Comment += "\n"
+			}
+			gt.Comment += patternsComment(s.PatternProperties)
+			gt.TypePrefix = "map[string]interface{}"
+			if patternSchema != nil {
+				singularName := singularize(gt.origTypeName)
+				gotType := g.processType(patternSchema, singularName, s.Description, path+"/patternProperties/"+pointerToken(pattern), path)
+				if gotType == "" {
+					g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+					return ""
+				}
+				gt.TypePrefix = "map[string]"
+				gt.TypeRef = gotType
+			}
+		} else if !hasProps && !hasAllOf && isClosedObject(s) {
+			// no properties are allowed, so there are no fields
+			gt.TypePrefix = typeStruct
+			gt.Closed = true
+		} else {
+			gt.TypePrefix = "map[string]interface{}"
+		}
+	case typeArray:
+		// the items' nullability doesn't carry over to the slice, which is only nullable if its own type includes null
+		items, itemsKeyword := arrayItems(s)
+		switch arrayItemType := items.(type) {
+		case []interface{}:
+			if len(arrayItemType) == 1 {
+				singularName := singularize(gt.origTypeName)
+				typeSchema := getTypeSchema(arrayItemType[0])
+				gotType := g.processType(typeSchema, singularName, s.Description, path+"/"+itemsKeyword+"/0", path)
+				if gotType == "" {
+					g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+					return ""
+				}
+				gt.TypePrefix = "[]"
+				gt.TypeRef = gotType
+			} else {
+				gt.TypePrefix = typeEmptyInterfaceSlice
+				if gt.Comment != "" {
+					gt.Comment += "\n"
+				}
+				gt.Comment += tupleComment(arrayItemType)
+			}
+		case bool:
+			gt.TypePrefix = typeEmptyInterfaceSlice
+			if !arrayItemType {
+				if gt.Comment != "" {
+					gt.Comment += "\n"
+				}
+				gt.Comment += noItemsComment
+			}
+		case interface{}:
+			singularName := singularize(gt.origTypeName)
+			typeSchema := getTypeSchema(arrayItemType)
+			gotType := g.processType(typeSchema, singularName, s.Description, path+"/items", path)
+			if gotType == "" {
+				g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+				return ""
+			}
+			gt.TypePrefix = "[]"
+			gt.TypeRef = gotType
+		default:
+			gt.TypePrefix = typeEmptyInterfaceSlice
+		}
+	default:
+		gt.TypePrefix = ts
+		if isMixedEnum(s.Enum) {
+			gt.TypePrefix = typeEmptyInterface
+			if gt.Comment != "" {
+				gt.Comment += "\n"
+			}
+			gt.Comment += enumValuesComment(s.Enum)
+		} else if len(s.Enum) > 0 {
+			switch ts {
+			case typeString, typeInt, typeInt32, typeInt64, typeUint32, typeUint64:
+				gt.Enum = nonNullEnum(s.Enum)
+				gt.EnumNames = g.enumVarNames(s, path)
+				gt.EnumError = g.isErrorEnum(s, gt.origTypeName)
+			default:
+				if _, ok := g.formatTypes[ts]; ok {
+					g.warnNonConstantEnum(path, ts)
+				}
+			}
+		}
+	}
+
+	for _, prop := range properties {
+		propName, propSchema, refPath := prop.name, prop.schema, prop.path
+		sf := structField{
+			PropertyName: propName,
+			Required:     required.Has(propName),
+			Validate:     g.validateRule(propSchema),
+			Access:       propertyAccess(propSchema),
+			Deprecated:   deprecatedComment(propSchema),
+			Default:      propSchema.Default,
+			Pattern:      propSchema.Pattern,
+			Comment:      propSchema.Description,
+		}
+
+		if len(propSchema.GoTags) > 0 {
+			sf.ExtraTags = make(map[string]string, len(propSchema.GoTags))
+			for key, val := range propSchema.GoTags {
+				sf.ExtraTags[key] = string(val)
+			}
+		}
+
+		var fieldName string
+		if propSchema.Title != "" {
+			fieldName = propSchema.Title
+		} else {
+			fieldName = propName
+		}
+		if g.UnexportPattern != nil && g.UnexportPattern.MatchString(propName) {
+			sf.Unexported = true
+			sf.Name = g.generateIdentifier(fieldName, false)
+		} else {
+			sf.Name = g.generateFieldName(fieldName)
+		}
+		if sf.Name == "" {
+			g.fail("can't generate field without name at %s", refPath)
+		}
+
+		if goType := g.goTypeOverride(propSchema, refPath); goType != "" {
+			sf.TypePrefix = goType
+			if propTypes, ok := propSchema.Type.([]interface{}); ok {
+				_, sf.Nullable = parseTypeArray(propTypes)
+			}
+			sf.Nullable = sf.Nullable || propSchema.Nullable
+			sf.Example = exampleComment(propSchema.Examples)
+			gt.Fields = append(gt.Fields, sf)
+			continue
+		}
+
+		if propSchema.Ref != "" {
+			g.processExternalRef(propSchema.Ref, path)
+			// a definition that is only a $ref has no type of its own, so the field refers to the type it resolves to
+			ref, ok := g.transitiveRefs[propSchema.Ref]
+			if !ok {
+				ref = propSchema.Ref
+			}
+			g.processLocalRef(ref, path)
+			if refType, ok := g.types[ref]; ok {
+				sf.TypeRef, sf.Nullable = ref, refType.Nullable || propSchema.Nullable
+				if refType.TypePrefix == typeStruct {
+					sf.PtrForOmit = true
+				}
+				gt.Fields = append(gt.Fields, sf)
+				continue
+			}
+			g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+			return ""
+		}
+
+		switch propType := propSchema.Type.(type) {
+		case []interface{}:
+			var jsonType string
+			jsonType, sf.Nullable = parseTypeArray(propType)
+			// a union of several types can only be held by an interface
+			sf.TypePrefix = typeEmptyInterface
+			if jsonType != "" {
+				sf.TypePrefix = g.getTypeString(jsonType, propSchema.Format)
+			}
+		case string:
+			sf.TypePrefix = g.getTypeString(propType, propSchema.Format)
+		case nil:
+			sf.TypePrefix = typeEmptyInterface
+			if propSchema.Const != nil {
+				sf.TypePrefix = g.getTypeString(constJSONType(propSchema.Const), propSchema.Format)
+			}
+		}
+		if propSchema.Nullable || enumHasNull(propSchema.Enum) {
+			sf.Nullable = true
+		}
+		if propSchema.Const != nil {
+			if sf.Comment != "" {
+				sf.Comment += "\n"
+			}
+			sf.Comment += constValueComment(propSchema.Const)
+		}
+
+		if g.OneOfInterfaces && len(propSchema.OneOf) > 0 && len(propSchema.Properties) == 0 {
+			gotType := g.processType(propSchema, fieldName, propSchema.Description, refPath, path)
+			if gotType == "" {
+				g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+				return ""
+			}
+			sf.TypePrefix = ""
+			sf.TypeRef = gotType
+			sf.Nullable = false
+		} else if isMixedEnum(propSchema.Enum) {
+			sf.TypePrefix = typeEmptyInterface
+			if sf.Comment != "" {
+				sf.Comment += "\n"
+			}
+			sf.Comment += enumValuesComment(propSchema.Enum)
+		} else if len(propSchema.Enum) > 0 {
+			switch sf.TypePrefix {
+			case typeString, typeInt, typeInt32, typeInt64, typeUint32, typeUint64:
+				// enums get a named type for their constants
+				gotType := g.processType(propSchema, fieldName, propSchema.Description, refPath, path)
+				if gotType == "" {
+					g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+					return ""
+				}
+				sf.TypePrefix = ""
+				sf.TypeRef = gotType
+			default:
+				if _, ok := g.formatTypes[sf.TypePrefix]; ok {
+					g.warnNonConstantEnum(refPath, sf.TypePrefix)
+				}
+			}
+		}
+
+		props := getTypeSchemas(propSchema.Properties)
+		hasProps := len(props) > 0
+		hasAddlProps, addlPropsSchema := parseAdditionalProperties(propSchema.AdditionalProperties)
+
+		if sf.TypePrefix == typeObject {
+			if hasProps && g.collapsesInline(propSchema, refPath) {
+				sf.TypePrefix = "map[string]interface{}"
+			} else if hasProps {
+				gotType := g.processType(propSchema, sf.Name, propSchema.Description, refPath, path)
+				if gotType == "" {
+					g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+					return ""
+				}
+				sf.TypePrefix = ""
+				sf.TypeRef = gotType
+				sf.PtrForOmit = true
+			} else if !hasProps && hasAddlProps && addlPropsSchema != nil {
+				singularName := singularize(propName)
+				gotType := g.processType(addlPropsSchema, singularName, propSchema.Description, refPath+"/additionalProperties", path)
+				if gotType == "" {
+					g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+					return ""
+				}
+				sf.TypePrefix = "map[string]"
+				sf.TypeRef = gotType
+			} else if !hasProps && addlPropsSchema == nil && len(propSchema.PatternProperties) > 0 {
+				pattern, patternSchema := patternValues(propSchema.PatternProperties)
+				if sf.Comment != "" {
+					sf.Comment += "\n"
+				}
+				sf.Comment += patternsComment(propSchema.PatternProperties)
+				sf.TypePrefix = "map[string]interface{}"
+				if patternSchema != nil {
+					singularName := singularize(propName)
+					gotType := g.processType(patternSchema, singularName, propSchema.Description, refPath+"/patternProperties/"+pointerToken(pattern), path)
+					if gotType == "" {
+						g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+						return ""
+					}
+					sf.TypePrefix = "map[string]"
+					sf.TypeRef = gotType
+				}
+			} else if !hasProps && isClosedObject(propSchema) {
+				sf.TypePrefix = typeEmptyStruct
+			} else {
+				sf.TypePrefix = "map[string]interface{}"
+			}
+		} else if sf.TypePrefix == typeArray {
+			items, itemsKeyword := arrayItems(propSchema)
+			switch arrayItemType := items.(type) {
+			case []interface{}:
+				if len(arrayItemType) == 1 {
+					singularName := singularize(propName)
+					typeSchema := getTypeSchema(arrayItemType[0])
+					gotType := g.processType(typeSchema, singularName, propSchema.Description, refPath+"/"+itemsKeyword+"/0", path)
+					if gotType == "" {
+						g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+						return ""
+					}
+					sf.TypePrefix = "[]"
+					sf.TypeRef = gotType
+				} else {
+					sf.TypePrefix = typeEmptyInterfaceSlice
+					if sf.Comment != "" {
+						sf.Comment += "\n"
+					}
+					sf.Comment += tupleComment(arrayItemType)
+				}
+			case bool:
+				sf.TypePrefix = typeEmptyInterfaceSlice
+				if !arrayItemType {
+					if sf.Comment != "" {
+						sf.Comment += "\n"
+					}
+					sf.Comment += noItemsComment
+				}
+			case interface{}:
+				singularName := singularize(propName)
+				typeSchema := getTypeSchema(arrayItemType)
+				if g.collapsesInline(typeSchema, refPath+"/items") {
+					sf.TypePrefix = "[]map[string]interface{}"
+					break
+				}
+				gotType := g.processType(typeSchema, singularName, propSchema.Description, refPath+"/items", path)
+				if gotType == "" {
+					g.deferredTypes[path] = deferredType{schema: s, name: pName, desc: pDesc, parentPath: parentPath}
+					return ""
+				}
+				sf.TypePrefix = "[]"
+				sf.TypeRef = gotType
+			default:
+				sf.TypePrefix = typeEmptyInterfaceSlice
+			}
+		}
+		if sf.TypeRef != refPath {
+			// a type generated from the property has the example in its own comment
+			sf.Example = exampleComment(propSchema.Examples)
+		}
+		sf.JSONString = g.jsonString(propSchema, sf, refPath)
+
+		gt.Fields = append(gt.Fields, sf)
+	}
+
+	embeddedProps := stringset.New()
+	for _, ref := range allOfRefs {
+		if g.AllOfEmbed && g.hasJSONMethods(g.types[ref]) {
+			// the methods would be promoted to the type and decode or encode only the embedded fields
+			g.warnf("Copying the fields of %s into %s instead of embedding it: it has its own JSON methods\n", g.types[ref].Name, gt.Name)
+		} else if g.AllOfEmbed {
+			gt.Fields = append(gt.Fields, structField{Embedded: true, TypeRef: ref})
+			for _, sf := range g.types[ref].Fields {
+				embeddedProps.Add(sf.PropertyName)
+			}
+			continue
+		}
+		for _, sf := range g.types[ref].Fields {
+			if sf.Overflow {
+				// only the schema's own additionalProperties decide whether the type keeps other properties
+				continue
+			}
+			sf.Required = sf.Required || required.Has(sf.PropertyName)
+			gt.Fields = append(gt.Fields, sf)
+		}
+	}
+	if hasAllOf {
+		gt.Fields = g.mergeFields(gt.Fields, path)
+	}
+	if overflow != nil {
+		if gt.hasUnexportedFields() {
+			g.warnf("Dropping additional properties of %s: its unexported fields need their own JSON methods\n", gt.Name)
+		} else {
+			gt.Fields = append(gt.Fields, *overflow)
+		}
+	}
+	if embeddedProps.Len() > 0 {
+		// an untyped property only refines the embedded field, which it shouldn't hide
+		fields := gt.Fields[:0]
+		for _, sf := range gt.Fields {
+			if !sf.isUntyped() || !embeddedProps.Has(sf.PropertyName) {
+				fields = append(fields, sf)
+			}
+		}
+		gt.Fields = fields
+	}
+	// fields are in schema order: the properties, then the fields of $ref members of allOf
+	for i := range gt.Fields {
+		gt.Fields[i].Order = i
+	}
+	gt.Fields = g.dedupeFieldNames(gt.Fields)
+
+	return
+}
+
+func (g *generator) processDeferred() {
+	for len(g.deferredTypes) > 0 {
+		startDeferredPaths, _ := stringset.FromMapKeys(g.deferredTypes)
+		for _, path := range startDeferredPaths.Sorted() {
+			deferred := g.deferredTypes[path]
+			name := g.processType(deferred.schema, deferred.name, deferred.desc, path, deferred.parentPath)
+			if name != "" {
+				delete(g.deferredTypes, path)
+				g.deferredResolved++
+			}
+		}
+
+		// if the list is the same as before, we're stuck
+		endDeferredPaths, _ := stringset.FromMapKeys(g.deferredTypes)
+		if endDeferredPaths.Equals(startDeferredPaths) {
+			g.fail("can't resolve: %v", startDeferredPaths)
+		}
+	}
+}
+
+// dedupeTypes renames types that share a name by prefixing the names of their parents, parents before children. A type
+// with no parent to name it after, such as the root type or a definition of a schema document, keeps its name. Names
+// and paths are visited in sorted order, so the same names are generated on every run.
+func (g *generator) dedupeTypes() {
+	for len(g.typesByName) > 0 {
+		typeNames, _ := stringset.FromMapKeys(g.typesByName)
+		sortedTypeNames := typeNames.Sorted()
+
+		// clear all singles first; otherwise some types will not be disambiguated
+		for _, name := range sortedTypeNames {
+			if len(g.typesByName[name]) == 1 {
+				g.typesByName.delete(name)
+			}
+		}
+
+		newTypesByName := make(stringSetMap)
+
+		for _, name := range sortedTypeNames {
+			dupes, ok := g.typesByName[name]
+			if !ok {
+				continue
+			}
+			// delete these dupes; will put back in as necessary in subsequent loop
+			g.typesByName.delete(name)
+
+			var kept string
+		dupesLoop:
+			for _, dupePath := range dupes.Sorted() {
+				gt := g.types[dupePath]
+				gt.ambiguityDepth++
+
+				topChild, topPath := gt, dupePath
+				var parent goType
+				for i := 0; i < gt.ambiguityDepth; i++ {
+					if isDocDefinition(topPath) {
+						// definitions are named by the schema, not after the schema containing them
+						parent = goType{}
+						break
+					}
+					parent = g.types[topChild.parentPath]
+
+					// handle parents before children to avoid stuttering
+					if g.typesByName.has(parent.Name) {
+						// add back the child to be processed later
+						newTypesByName.addTo(gt.Name, dupePath)
+						gt.ambiguityDepth--
+						continue dupesLoop
+					}
+
+					topChild, topPath = parent, topChild.parentPath
+				}
+
+				if parent.origTypeName == "" {
+					if kept != "" {
+						g.fail("can't disambiguate: %v", dupes)
+					}
+					// the others are renamed instead
+					kept = dupePath
+					continue
+				}
+
+				gt.origTypeName = parent.origTypeName + "-" + gt.origTypeName
+
+				if _, ok := g.renamedTypes[dupePath]; !ok {
+					g.renamedTypes[dupePath] = gt.Name
+				}
+				gt.Name = g.generateTypeName(gt.origTypeName)
+				g.types[dupePath] = gt
+
+				// add with new name in case we still have dupes
+				newTypesByName.addTo(gt.Name, dupePath)
+			}
+		}
+		g.typesByName = newTypesByName
+	}
+}
+
+// isDocDefinition returns true if path is a definition of a whole schema document, e.g. #/definitions/address.
+func isDocDefinition(path string) bool {
+	_, pointer := splitRef(path)
+	tokens := strings.Split(pointer, "/")
+	return len(tokens) == 3 && (tokens[1] == "definitions" || tokens[1] == "$defs")
+}
+
+func (g *generator) parseDefs(s *metaSchema, path string) {
+	for _, keyword := range []string{"definitions", "$defs"} {
+		schemas := s.Definitions
+		if keyword == "$defs" {
+			schemas = s.Defs
+		}
+		defs := getTypeSchemas(schemas)
+		// process definitions in order so that the same ones are deferred each time
+		defNames, _ := stringset.FromMapKeys(defs)
+		for _, defName := range defNames.Sorted() {
+			defSchema := defs[defName]
+			defPath := path + "/" + keyword + "/" + pointerToken(defName)
+			name := g.processType(defSchema, defName, defSchema.Description, defPath, path)
+			if name == "" {
+				g.deferredTypes[defPath] = deferredType{schema: defSchema, name: defName, desc: defSchema.Description, parentPath: path}
+			}
+		}
+	}
+}
+
+// prune removes the types that aren't generated: with OnlyTypes, those that the named types don't reference, and with
+// PruneTypes, those that the type at rootPath doesn't reference. KeepTypes are kept either way.
+func (g *generator) prune(rootPath string) {
+	switch {
+	case len(g.OnlyTypes) > 0:
+		names := stringset.New()
+		for _, gt := range g.types {
+			names.Add(gt.Name)
+		}
+		for _, name := range g.OnlyTypes {
+			if !names.Has(name) {
+				g.fail("there is no type named %s to generate", name)
+			}
+		}
+		g.pruneUnreferenced("", append(append([]string{}, g.OnlyTypes...), g.KeepTypes...))
+	case g.PruneTypes:
+		g.pruneUnreferenced(rootPath, g.KeepTypes)
+	}
+}
+
+// pruneUnreferenced removes all types that aren't transitively referenced by the type at rootPath, if it isn't "", or
+// by a type named in keepNames.
+func (g *generator) pruneUnreferenced(rootPath string, keepNames []string) {
+	if ref, ok := g.transitiveRefs[rootPath]; ok {
+		rootPath = ref
+	}
+	pending := []string{rootPath}
+	keep := stringset.New(keepNames...)
+	for path, gt := range g.types {
+		if keep.Has(gt.Name) {
+			pending = append(pending, path)
+		}
+	}
+
+	referenced := stringset.New()
+	for len(pending) > 0 {
+		path := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		gt, ok := g.types[path]
+		if !ok || referenced.Has(path) {
+			continue
+		}
+		referenced.Add(path)
+
+		pending = append(pending, gt.TypeRef)
+		pending = append(pending, gt.Variants...)
+		for _, sf := range gt.Fields {
+			pending = append(pending, sf.TypeRef)
+		}
+	}
+
+	for path := range g.types {
+		if !referenced.Has(path) {
+			delete(g.types, path)
+		}
+	}
+}
+
+// breakCycles marks the struct fields that would make a type contain itself by value, which Go doesn't allow, as
+// recursive so they're printed according to --recursion-strategy. Fields that are already pointers, slices, or maps
+// don't need breaking. Types are visited in path order so the same fields are marked on every run.
+func (g *generator) breakCycles() {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+
+	var visit func(path string)
+	visit = func(path string) {
+		state[path] = visiting
+		gt := g.types[path]
+		if _, ok := g.types[gt.TypeRef]; ok && gt.TypePrefix == "" && state[gt.TypeRef] == unvisited {
+			visit(gt.TypeRef)
+		}
+		for i, sf := range gt.Fields {
+			if _, ok := g.types[sf.TypeRef]; !ok || sf.TypePrefix != "" || g.isSliceOrMap(sf) {
+				continue
+			}
+			if g.isPointer(sf) {
+				continue
+			}
+			switch state[sf.TypeRef] {
+			case unvisited:
+				visit(sf.TypeRef)
+			case visiting:
+				gt.Fields[i].Recursive = true
+			}
+		}
+		g.types[path] = gt
+		state[path] = visited
+	}
+
+	paths := make([]string, 0, len(g.types))
+	for path := range g.types {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if state[path] == unvisited {
+			visit(path)
+		}
+	}
+}
+
+// generate processes the schema s, starting with the root type.
+func (g *generator) generate(s *metaSchema) {
+	g.rootSchema = s
+	g.processType(s, g.RootTypeName, s.Description, "#", "")
+	g.processDeferred()
+	g.dedupeTypes()
+	g.prune("#")
+	g.breakCycles()
+}
+
+// render prints all processed types as a formatted Go source file. If formatting fails, the unformatted source is
+// returned along with the error.
+func (g *generator) render() ([]byte, error) {
+	var typesSrc bytes.Buffer
+	for _, gt := range g.sortedTypes() {
+		g.printTypeDecls(&typesSrc, gt)
+	}
+	if g.needPasswordType {
+		g.printPasswordType(&typesSrc)
+		typesSrc.WriteString("\n")
+	}
+	for _, format := range g.civilTypes.Sorted() {
+		g.printCivilType(&typesSrc, format)
+		typesSrc.WriteString("\n")
+	}
+	if g.EmitPtrHelpers {
+		g.printPtrHelper(&typesSrc)
+	}
+	if g.NDJSONDecoder && (len(g.OnlyTypes) == 0 || g.hasRootType()) {
+		typesSrc.WriteString("\n")
+		g.printNDJSONDecoder(&typesSrc)
+	}
+	if g.usesOrderedMap(typesSrc.String()) {
+		typesSrc.WriteString("\n")
+		g.printOrderedMap(&typesSrc)
+	}
+
+	imports, err := g.usedImports(typesSrc.Bytes())
+	if err != nil {
+		// the declarations don't parse, so formatting the file without imports fails with the same error, whose line
+		// is in the returned source
+		return g.formatFile(typesSrc.Bytes(), nil)
+	}
+	return g.formatFile(typesSrc.Bytes(), imports)
+}
+
+// sortedTypes returns the processed types sorted by name.
+func (g *generator) sortedTypes() goTypes {
+	typesSlice := make(goTypes, 0, len(g.types))
+	for _, gt := range g.types {
+		typesSlice = append(typesSlice, gt)
+	}
+	sort.Stable(typesSlice)
+	return typesSlice
+}
+
+// printTypeDecls prints the type along with its methods and builder.
+func (g *generator) printTypeDecls(buf *bytes.Buffer, gt goType) {
+	g.printType(buf, gt)
+	buf.WriteString("\n")
+	discriminatedFields := g.discriminatedFields(gt)
+	if gt.hasUnexportedFields() {
+		if len(g.nullFields(gt)) > 0 {
+			g.warnf("Not writing null for the nil fields of %s: it has unexported fields\n", gt.Name)
+		}
+		if len(discriminatedFields) > 0 {
+			g.warnf("Not unmarshalling the oneOf fields of %s by their discriminators: it has unexported fields\n", gt.Name)
+		}
+		g.printUnexportedCodec(buf, gt)
+		buf.WriteString("\n")
+	} else if overflow, ok := gt.overflowField(); ok {
+		if len(discriminatedFields) > 0 {
+			g.warnf("Not unmarshalling the oneOf fields of %s by their discriminators: it has %s\n", gt.Name, overflow.Name)
+		}
+		g.printOverflowCodec(buf, gt, overflow)
+		buf.WriteString("\n")
+	} else {
+		if nullFields := g.nullFields(gt); len(nullFields) > 0 {
+			g.printNullMarshal(buf, gt, nullFields)
+			buf.WriteString("\n")
+		}
+		if len(discriminatedFields) > 0 {
+			g.printDiscriminatedUnmarshal(buf, gt, discriminatedFields)
+			buf.WriteString("\n")
+		} else if g.isStrict(gt) {
+			g.printStrictUnmarshal(buf, gt)
+			buf.WriteString("\n")
+		}
+	}
+	if g.GenSQL && gt.isSQLScalar() {
+		g.printSQLMethods(buf, gt)
+		buf.WriteString("\n")
+	}
+	if g.Constructors && gt.TypePrefix == typeStruct {
+		g.printConstructor(buf, gt)
+		buf.WriteString("\n")
+	}
+	if g.GenBuilders && gt.TypePrefix == typeStruct {
+		g.printBuilder(buf, gt)
+		buf.WriteString("\n")
+	}
+}
+
+// usesOrderedMap returns true if the ordered map type is used in src, so that it needs to be printed.
+func (g *generator) usesOrderedMap(src string) bool {
+	return g.MapType == MapTypeOrdered && strings.Contains(src, g.orderedMapName()+"[")
+}
+
+// formatFile returns the formatted source of a generated file with the declarations in body and the given imports,
+// which may have duplicates. If formatting fails, the unformatted source is returned along with the error.
+func (g *generator) formatFile(body []byte, imports []string) ([]byte, error) {
+	var resultSrc bytes.Buffer
+	if !g.NoBanner {
+		// tools only recognize generated files by a banner before the package clause
+		resultSrc.WriteString(fileComment(g.Banner) + "\n")
+	}
+	if g.BuildTags != "" {
+		// a build constraint must precede the package clause and be followed by a blank line
+		resultSrc.WriteString(fmt.Sprintf("//go:build %s\n\n", g.BuildTags))
+	}
+	pkgName := g.PackageName
+	if g.printPkg != "" {
+		pkgName = importPathName(g.printPkg)
+	}
+	resultSrc.WriteString(fmt.Sprintln("package", pkgName))
+	if g.FileComment != "" {
+		resultSrc.WriteString("\n" + fileComment(g.FileComment))
+	}
+	resultSrc.WriteString("\n")
+	g.writeImports(&resultSrc, imports)
+	resultSrc.Write(body)
+	if g.NoFormat {
+		return resultSrc.Bytes(), nil
+	}
+	formattedSrc, err := format.Source(resultSrc.Bytes())
+	if err != nil {
+		return resultSrc.Bytes(), formatError(resultSrc.Bytes(), err)
+	}
+	return formattedSrc, nil
+}
+
+// fileComment returns the lines of comment as line comments, adding "// " to the lines that aren't comments already,
+// so that directives such as //nolint:all are kept as they are.
+func fileComment(comment string) string {
+	var buf bytes.Buffer
+	for _, line := range strings.Split(comment, "\n") {
+		if !strings.HasPrefix(line, "//") {
+			line = strings.TrimSpace("// " + line)
+		}
+		buf.WriteString(line + "\n")
+	}
+	return buf.String()
+}
+
+// formatError adds the line of src that err, an error from formatting src, points to, so that it can be found in the
+// unformatted source.
+func formatError(src []byte, err error) error {
+	errs, ok := err.(scanner.ErrorList)
+	if !ok || len(errs) == 0 {
+		return err
+	}
+	lines := bytes.Split(src, []byte("\n"))
+	line := errs[0].Pos.Line
+	if line < 1 || line > len(lines) {
+		return err
+	}
+	return fmt.Errorf("%s\nline %d: %s", err, line, bytes.TrimSpace(lines[line-1]))
+}
+
+// hasRootType returns true if the root type is generated, which it isn't if OnlyTypes leaves it out.
+func (g *generator) hasRootType() bool {
+	rootPath := "#"
+	if ref, ok := g.transitiveRefs[rootPath]; ok {
+		rootPath = ref
+	}
+	_, ok := g.types[rootPath]
+	return ok
+}
+
+// printNDJSONDecoder writes a function that decodes newline-delimited JSON into a slice with one element per line. If
+// the root type is a slice, the function returns it; otherwise it returns a slice of the root type.
+func (g *generator) printNDJSONDecoder(buf *bytes.Buffer) {
+	rootPath := "#"
+	if ref, ok := g.transitiveRefs[rootPath]; ok {
+		rootPath = ref
+	}
+	root := g.types[rootPath]
+
+	sliceType, elemType := "[]"+root.Name, root.Name
+	if strings.HasPrefix(root.TypePrefix, "[]") {
+		sliceType = root.Name
+		elemType = strings.TrimPrefix(root.TypePrefix, "[]")
+		if elem, ok := g.types[root.TypeRef]; ok {
+			elemType += elem.Name
+		}
+	}
+
+	funcName := "decode" + g.generateIdentifier(root.Name, true) + "NDJSON"
+	if unicode.IsUpper([]rune(root.Name)[0]) {
+		funcName = "D" + funcName[1:]
+	}
+	buf.WriteString(fmt.Sprintf("// %s decodes newline-delimited JSON from r, with one %s per line.\n", funcName, elemType))
+	buf.WriteString(fmt.Sprintf("func %s(r io.Reader) (%s, error) {\n", funcName, sliceType))
+	buf.WriteString(fmt.Sprintf("var result %s\ndec := json.NewDecoder(r)\nfor {\nvar item %s\n", sliceType, elemType))
+	buf.WriteString("if err := dec.Decode(&item); err == io.EOF {\nreturn result, nil\n} else if err != nil {\nreturn result, err\n}\n")
+	buf.WriteString("result = append(result, item)\n}\n}\n")
+}
+
+func (g *generator) passwordTypeName() string {
+	return g.generateIdentifier("password", g.exportTypes())
+}
+
+// printPasswordType prints the string type used for properties with format password under --redact-passwords.
+func (g *generator) printPasswordType(buf *bytes.Buffer) {
+	name := g.passwordTypeName()
+	buf.WriteString(fmt.Sprintf("// %s is a string that hides its value when formatted, so that it isn't logged. It is\n", name))
+	buf.WriteString("// marshalled to JSON as is.\n")
+	buf.WriteString(fmt.Sprintf("type %s string\n", name))
+	printRedactedMethods(buf, name)
+}
+
+// printRedactedMethods prints the fmt.Stringer and fmt.GoStringer methods that hide the values of a password type.
+func printRedactedMethods(buf *bytes.Buffer, name string) {
+	buf.WriteString("\n// String returns a placeholder instead of the value.\n")
+	buf.WriteString(fmt.Sprintf("func (%s) String() string {\nreturn \"[REDACTED]\"\n}\n", name))
+	buf.WriteString("\n// GoString returns a placeholder instead of the value.\n")
+	buf.WriteString(fmt.Sprintf("func (%s) GoString() string {\nreturn \"[REDACTED]\"\n}\n", name))
+}
+
+func (g *generator) ptrHelperName() string {
+	return g.generateIdentifier("ptr", g.exportTypes())
+}
+
+// printPtrHelper writes a generic function returning a pointer to its argument, so that optional scalar fields can be
+// set inline (e.g. Ptr(5) for an *int).
+func (g *generator) printPtrHelper(buf *bytes.Buffer) {
+	name := g.ptrHelperName()
+	buf.WriteString(fmt.Sprintf("// %s returns a pointer to v.\n", name))
+	buf.WriteString(fmt.Sprintf("func %s[T any](v T) *T {\nreturn &v\n}\n", name))
+}
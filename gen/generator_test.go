@@ -0,0 +1,3583 @@
+package gen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// opts are the options used by generateFromString, which tests change from the defaults set by resetGenerator.
+var opts Options
+
+// resetGenerator restores opts to the defaults used by the tests.
+func resetGenerator() {
+	opts = Options{RootTypeName: "schema"}
+}
+
+// generateFromString runs the generator over schemaJSON with the current options.
+func generateFromString(schemaJSON string) (string, error) {
+	src, err := Generate([]byte(schemaJSON), opts)
+	return string(src), err
+}
+
+var spaceRun = regexp.MustCompile(`[ \t]+`)
+
+// compact collapses runs of spaces and tabs so assertions don't depend on gofmt's alignment.
+func compact(src string) string {
+	return spaceRun.ReplaceAllString(src, " ")
+}
+
+// typeCheck parses and type-checks the given sources as a single package.
+func typeCheck(srcs ...string) error {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, src := range srcs {
+		file, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	conf := gotypes.Config{Importer: importer.Default()}
+	_, err := conf.Check("main", fset, files, nil)
+	return err
+}
+
+// runGenerated runs the generated source together with mainSrc, which should declare func main, as a program in a
+// temporary module and returns its combined output.
+func runGenerated(src, mainSrc string) (string, error) {
+	return goCommand(src, mainSrc, "run", ".")
+}
+
+// vetGenerated runs go vet on the generated source, which is in package main, and returns its output.
+func vetGenerated(src string) (string, error) {
+	return goCommand(src, "package main\n\nfunc main() {}\n", "vet", ".")
+}
+
+// goCommand runs the go command with args in a module holding the generated source and mainSrc, and returns its
+// output.
+func goCommand(src, mainSrc string, args ...string) (string, error) {
+	dir, err := ioutil.TempDir("", "schematyper")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"go.mod":             "module generated\n\ngo 1.18\n",
+		"schematype.go":      src,
+		"schematype_main.go": mainSrc,
+	}
+	for name, contents := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			return "", err
+		}
+	}
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestEmitPointerHelpers(t *testing.T) {
+	Convey("Given a schema with optional scalar properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"count": {"type": "integer"},
+				"name": {"type": "string"}
+			}
+		}`
+
+		Convey("When we generate without --emit-pointer-helpers", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then no helper should be emitted", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldNotContainSubstring, "func ptr")
+			})
+		})
+
+		Convey("When we generate with --emit-pointer-helpers", func() {
+			opts.EmitPtrHelpers = true
+			src, err := generateFromString(schema)
+
+			Convey("Then a generic helper should be emitted", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "func ptr[T any](v T) *T {")
+			})
+
+			Convey("Then the helper should be usable for scalar pointers", func() {
+				usage := "package main\n\nvar _ *int = ptr(5)\nvar _ *string = ptr(\"five\")\n"
+				So(typeCheck(src, usage), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate for a non-main package", func() {
+			opts.EmitPtrHelpers = true
+			opts.PackageName = "schemas"
+			src, err := generateFromString(schema)
+
+			Convey("Then the helper should be exported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "func Ptr[T any](v T) *T {")
+			})
+		})
+	})
+}
+
+func TestExtraGoTags(t *testing.T) {
+	Convey("Given a schema with an x-go-tags property extension", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"id": {
+					"type": "integer",
+					"x-go-tags": {"gorm": "primaryKey", "db": "id"}
+				},
+				"name": {"type": "string"}
+			},
+			"required": ["id"]
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the extra tags should follow the json tag, sorted and quoted", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "`json:\"id\" db:\"id\" gorm:\"primaryKey\"`")
+			})
+
+			Convey("Then properties without the extension should only have a json tag", func() {
+				So(compact(src), ShouldContainSubstring, "`json:\"name,omitempty\"`")
+			})
+		})
+	})
+}
+
+func TestNullType(t *testing.T) {
+	Convey("Given a schema with a property of type null", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"nothing": {"type": "null"}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the field should be an empty interface", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Nothing interface{}")
+			})
+
+			Convey("Then the output should compile", func() {
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a standalone schema of type null", t, func() {
+		resetGenerator()
+		schema := `{"type": "null"}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the type should be an empty interface and compile", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type schema interface{}")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestNoPointersForSlicesAndMaps(t *testing.T) {
+	Convey("Given a schema with nullable and optional slices and maps", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"tags": {"type": ["array", "null"], "items": {"type": "string"}},
+				"labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+				"ids": {"$ref": "#/definitions/ids"},
+				"name": {"type": ["string", "null"]}
+			},
+			"definitions": {
+				"ids": {"type": ["array", "null"], "items": {"type": "integer"}}
+			}
+		}`
+
+		Convey("When we generate with --ptr-for-omit", func() {
+			opts.PtrForOmit = true
+			src, err := generateFromString(schema)
+
+			Convey("Then slice and map fields should not be pointers", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Tags []tag")
+				So(compact(src), ShouldContainSubstring, "Labels map[string]label")
+				So(compact(src), ShouldContainSubstring, "Ids ids")
+			})
+
+			Convey("Then nullable scalars should still be pointers", func() {
+				So(compact(src), ShouldContainSubstring, "Name *string")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestNullableArrayItems(t *testing.T) {
+	Convey("Given arrays of nullable items and nullable arrays", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"items": {"type": "array", "items": {"$ref": "#/definitions/item"}},
+				"names": {"type": "array", "items": {"type": ["string", "null"]}},
+				"maybeItems": {"type": ["array", "null"], "items": {"$ref": "#/definitions/item"}},
+				"list": {"$ref": "#/definitions/list"},
+				"maybeList": {"$ref": "#/definitions/maybeList"}
+			},
+			"definitions": {
+				"item": {"type": ["object", "null"], "properties": {"id": {"type": "string"}}},
+				"list": {"type": "array", "items": {"$ref": "#/definitions/item"}},
+				"maybeList": {"type": ["array", "null"], "items": {"$ref": "#/definitions/item"}}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the slices should hold the items by value", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, " Items []item `json:\"items,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Names []name `json:\"names,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " MaybeItems []item `json:\"maybeItems,omitempty\"`")
+				So(src, ShouldContainSubstring, "type list []item")
+				So(src, ShouldContainSubstring, "type maybeList []item")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --null-methods", func() {
+			opts.NullMethods = true
+			src, err := generateFromString(schema)
+
+			Convey("Then only the arrays that are nullable themselves should be written as null", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, `props["maybeItems"] = nil`)
+				So(src, ShouldContainSubstring, `props["maybeList"] = nil`)
+				So(src, ShouldNotContainSubstring, `props["items"] = nil`)
+				So(src, ShouldNotContainSubstring, `props["names"] = nil`)
+				So(src, ShouldNotContainSubstring, `props["list"] = nil`)
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestClosedEmptyObject(t *testing.T) {
+	Convey("Given a schema with objects that have no properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"closed": {"type": "object", "additionalProperties": false},
+				"open": {"type": "object"},
+				"marker": {"$ref": "#/definitions/marker"}
+			},
+			"definitions": {
+				"marker": {"type": "object", "additionalProperties": false}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then objects with additionalProperties false should be empty structs", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Closed struct{} `json:\"closed,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "type marker struct {\n}")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then objects with unspecified additionalProperties should still be maps", func() {
+				So(compact(src), ShouldContainSubstring, "Open map[string]interface{}")
+			})
+		})
+	})
+}
+
+func TestStrictUnmarshal(t *testing.T) {
+	Convey("Given a closed schema with an open nested object", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"name": {"type": "string"},
+				"inner": {"type": "object", "properties": {"id": {"type": "integer"}}}
+			}
+		}`
+
+		Convey("When we generate without --strict-unmarshal", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then there should be no UnmarshalJSON method", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "UnmarshalJSON")
+			})
+		})
+
+		Convey("When we generate with --strict-unmarshal", func() {
+			opts.StrictUnmarshal = true
+			src, err := generateFromString(schema)
+
+			Convey("Then only the closed struct should get an UnmarshalJSON method", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "func (v *schema) UnmarshalJSON(data []byte) error {")
+				So(src, ShouldNotContainSubstring, "func (v *inner) UnmarshalJSON")
+			})
+
+			Convey("Then unknown properties should be rejected only where the schema is closed", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, data := range []string{
+		` + "`" + `{"name": "a", "inner": {"id": 1, "extra": true}}` + "`" + `,
+		` + "`" + `{"name": "a", "extra": true}` + "`" + `,
+	} {
+		var s schema
+		err := json.Unmarshal([]byte(data), &s)
+		fmt.Println(s.Name, err)
+	}
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "a <nil>\n json: unknown field \"extra\"\n")
+			})
+		})
+
+		Convey("When we generate a closed struct with no properties with --strict-unmarshal", func() {
+			opts.StrictUnmarshal = true
+			src, err := generateFromString(`{"type": "object", "additionalProperties": false}`)
+
+			Convey("Then it should reject every property", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "switch name")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --strict-unmarshal and unexported fields", func() {
+			opts.StrictUnmarshal = true
+			opts.UnexportPattern = regexp.MustCompile("^name$")
+			src, err := generateFromString(schema)
+
+			Convey("Then the unexported fields' UnmarshalJSON should reject unknown properties", func() {
+				So(err, ShouldBeNil)
+				So(strings.Count(src, "UnmarshalJSON(data []byte)"), ShouldEqual, 1)
+				So(src, ShouldContainSubstring, "json: unknown field")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given an allOf with a $ref to a closed schema", t, func() {
+		resetGenerator()
+		schema := `{
+			"definitions": {
+				"base": {"type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}}
+			},
+			"allOf": [{"$ref": "#/definitions/base"}],
+			"properties": {"b": {"type": "string"}}
+		}`
+
+		Convey("When we generate with --strict-unmarshal and --allof-embed", func() {
+			opts.StrictUnmarshal = true
+			opts.AllOfEmbed = true
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			src, err := generateFromString(schema)
+
+			Convey("Then the closed type should be copied instead of embedded, with a warning", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type schema struct {\n A string `json:\"a,omitempty\"`\n B string `json:\"b,omitempty\"`\n}")
+				So(logs.String(), ShouldContainSubstring, "Copying the fields of base into schema instead of embedding it")
+			})
+
+			Convey("Then the embedding type should decode the properties of both", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var s schema
+	err := json.Unmarshal([]byte(` + "`" + `{"a": "x", "b": "y"}` + "`" + `), &s)
+	fmt.Println(s.A, s.B, err)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "x y <nil>\n")
+			})
+		})
+	})
+}
+
+func TestPatternProperties(t *testing.T) {
+	Convey("Given a schema with patternProperties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"counts": {"type": "object", "patternProperties": {"^[a-z]+$": {"type": "integer"}}},
+				"mixed": {
+					"type": "object",
+					"patternProperties": {"^s_": {"type": "string"}, "^i_": {"type": "integer"}}
+				},
+				"scores": {"$ref": "#/definitions/scores"}
+			},
+			"definitions": {
+				"scores": {
+					"type": "object",
+					"patternProperties": {"^a": {"type": "number"}, "^b": {"type": "number"}}
+				}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then a single pattern should be a map of its value type, with the pattern in a comment", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "// Keys match ^[a-z]+$.\n Counts map[string]count `json:\"counts,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "type count int")
+			})
+
+			Convey("Then patterns with the same schema should share the value type", func() {
+				So(src, ShouldContainSubstring, "// Keys match ^a or ^b.\ntype scores map[string]score")
+				So(compact(src), ShouldContainSubstring, "type score float64")
+			})
+
+			Convey("Then patterns with different schemas should fall back to interface{} values", func() {
+				So(compact(src), ShouldContainSubstring, "// Keys match ^i_ or ^s_.\n Mixed map[string]interface{}")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestPropertiesWithAdditionalProperties(t *testing.T) {
+	Convey("Given objects with both properties and additionalProperties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"labels": {
+					"type": "object",
+					"properties": {"color": {"type": "string"}},
+					"additionalProperties": true
+				}
+			},
+			"additionalProperties": {"type": "integer"}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then they should be structs with a map for the other properties", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "AdditionalProperties map[string]schemaItem `json:\"-\"`")
+				So(compact(src), ShouldContainSubstring, "AdditionalProperties map[string]interface{} `json:\"-\"`")
+				So(compact(src), ShouldContainSubstring, "Labels labels `json:\"labels,omitempty\"`")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then the other properties should round-trip through the map, with fields taking precedence", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var s schema
+	err := json.Unmarshal([]byte(` + "`" + `{"name": "a", "size": 3, "labels": {"color": "red", "shape": "round"}}` + "`" + `), &s)
+	fmt.Println(s.Name, s.AdditionalProperties, s.Labels.Color, s.Labels.AdditionalProperties, err)
+	data, err := json.Marshal(schema{Name: "b", AdditionalProperties: map[string]schemaItem{"size": 3, "name": 4}})
+	fmt.Println(string(data), err)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "a map[size:3] red map[shape:round] <nil>\n{\"labels\":{},\"name\":\"b\",\"size\":3} <nil>\n")
+			})
+		})
+	})
+}
+
+func TestAdditionalPropertiesRefs(t *testing.T) {
+	Convey("Given additionalProperties that refer to types defined later in the schema", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"byName": {"type": "object", "additionalProperties": {"$ref": "#/definitions/zebra"}},
+				"byAlias": {"type": "object", "additionalProperties": {"$ref": "#/definitions/alias"}},
+				"byZone": {"type": "object", "additionalProperties": {"$ref": "#/properties/zone"}},
+				"zone": {"type": "object", "properties": {"code": {"type": "string"}}}
+			},
+			"definitions": {
+				"alias": {"$ref": "#/definitions/zebra"},
+				"herd": {"type": "object", "additionalProperties": {"$ref": "#/definitions/zebra"}},
+				"pen": {"type": "object", "properties": {"size": {"type": "integer"}}, "additionalProperties": {"$ref": "#/definitions/zebra"}},
+				"zebra": {"type": "object", "properties": {"stripes": {"type": "integer"}}}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the maps should hold the referenced types once they are processed", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type herd map[string]zebra")
+				So(compact(src), ShouldContainSubstring, " AdditionalProperties map[string]zebra `json:\"-\"`")
+				So(compact(src), ShouldContainSubstring, " ByName map[string]zebra `json:\"byName,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " ByAlias map[string]zebra `json:\"byAlias,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " ByZone map[string]zone `json:\"byZone,omitempty\"`")
+				So(strings.Count(src, "type zebra struct"), ShouldEqual, 1)
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestNullMethods(t *testing.T) {
+	Convey("Given structs with optional nullable fields", t, func() {
+		schema := `{
+			"type": "object",
+			"properties": {
+				"name": {"type": ["string", "null"]},
+				"note": {"type": "string"},
+				"extra": {"nullable": true},
+				"id": {"type": ["integer", "null"]},
+				"meta": {
+					"type": "object",
+					"properties": {"owner": {"type": ["string", "null"]}},
+					"additionalProperties": true
+				},
+				"plain": {"type": "object", "properties": {"size": {"type": "integer"}}}
+			},
+			"required": ["id"]
+		}`
+
+		Convey("When we generate without NullMethods", func() {
+			resetGenerator()
+			src, err := generateFromString(schema)
+
+			Convey("Then there should be no MarshalJSON methods for them", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "func (v schema) MarshalJSON()")
+			})
+		})
+
+		Convey("When we generate with NullMethods", func() {
+			resetGenerator()
+			opts.NullMethods = true
+			src, err := generateFromString(schema)
+
+			Convey("Then only the structs with optional nullable fields should get a MarshalJSON method", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "// MarshalJSON encodes schema, writing null for its nullable fields that are nil.\n")
+				So(src, ShouldNotContainSubstring, "func (v plain) MarshalJSON()")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then the nil nullable fields should be written as null, and required ones as before", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	name := "a"
+	for _, s := range []schema{{}, {Name: &name, Extra: 1, Meta: meta{AdditionalProperties: map[string]interface{}{"x": 1}}}} {
+		data, err := json.Marshal(s)
+		fmt.Println(string(data), err)
+	}
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, `{"extra":null,"id":null,"meta":{"owner":null},"name":null,"plain":{}} <nil>`+"\n"+
+					`{"extra":1,"id":null,"meta":{"owner":null,"x":1},"name":"a","plain":{}} <nil>`+"\n")
+			})
+		})
+	})
+}
+
+func TestMaxInlineDepth(t *testing.T) {
+	Convey("Given deeply nested inline objects", t, func() {
+		schema := `{
+			"type": "object",
+			"properties": {
+				"a": {"type": "object", "properties": {
+					"b": {"type": "object", "properties": {
+						"c": {"type": "object", "properties": {"d": {"type": "string"}}},
+						"list": {"type": "array", "items": {"type": "object", "properties": {"e": {"type": "string"}}}}
+					}}
+				}}
+			},
+			"definitions": {
+				"wrapper": {"type": "object", "properties": {
+					"inner": {"type": "object", "properties": {"f": {"type": "string"}}}
+				}}
+			}
+		}`
+
+		Convey("When we generate without a maximum depth", func() {
+			resetGenerator()
+			src, err := generateFromString(schema)
+
+			Convey("Then every object should get a type", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type c struct")
+				So(src, ShouldContainSubstring, "type listItem struct")
+			})
+		})
+
+		Convey("When we generate with a maximum depth of 2", func() {
+			resetGenerator()
+			opts.MaxInlineDepth = 2
+			src, err := generateFromString(schema)
+
+			Convey("Then objects nested deeper should be maps", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type a struct")
+				So(src, ShouldContainSubstring, "type b struct")
+				So(compact(src), ShouldContainSubstring, " C map[string]interface{} `json:\"c,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " List []map[string]interface{} `json:\"list,omitempty\"`")
+				So(src, ShouldNotContainSubstring, "type c struct")
+				So(src, ShouldNotContainSubstring, "type listItem struct")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then the depth should start over in definitions", func() {
+				So(src, ShouldContainSubstring, "type inner struct")
+			})
+		})
+	})
+}
+
+func TestMixedTypeEnum(t *testing.T) {
+	Convey("Given a schema with enums mixing JSON types", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"state": {"enum": ["active", 1, true]},
+				"errorState": {"type": "string", "enum": ["failed", 2]},
+				"level": {"$ref": "#/definitions/level"}
+			},
+			"definitions": {
+				"level": {"description": "How loud to be.", "enum": ["high", 1.5, null]}
+			}
+		}`
+
+		Convey("When we generate with --enum-errors", func() {
+			opts.EnumErrors = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the fields should be empty interfaces listing the allowed values", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "// Allowed values: \"active\", 1, true.\n State interface{}")
+				So(compact(src), ShouldContainSubstring, "// Allowed values: \"failed\", 2.\n ErrorState interface{}")
+			})
+
+			Convey("Then a named type should list the allowed values after its description", func() {
+				So(compact(src), ShouldContainSubstring, "// How loud to be.\n// Allowed values: \"high\", 1.5, null.\ntype level interface{}")
+			})
+
+			Convey("Then no constants should be generated and the output should compile", func() {
+				So(src, ShouldNotContainSubstring, "const (")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a schema with enums that allow null", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"status": {"type": ["string", "null"], "enum": ["a", "b", null], "x-enum-varnames": ["First", "Second", "None"]},
+				"size": {"$ref": "#/definitions/size"}
+			},
+			"definitions": {
+				"size": {"type": "integer", "enum": [1, 2, null]}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then null should be ignored when checking the types of the values", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "interface{}")
+				So(src, ShouldNotContainSubstring, "Allowed values")
+			})
+
+			Convey("Then the enums should have constants for the other values only", func() {
+				So(compact(src), ShouldContainSubstring, "statusFirst status = \"a\"\n statusSecond status = \"b\"\n)")
+				So(compact(src), ShouldContainSubstring, "size1 size = 1\n size2 size = 2\n)")
+			})
+
+			Convey("Then the fields should be nullable", func() {
+				So(compact(src), ShouldContainSubstring, "Status *status `json:\"status,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "Size *size `json:\"size,omitempty\"`")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestOneOf(t *testing.T) {
+	Convey("Given a schema with oneOf properties", t, func() {
+		resetGenerator()
+		opts.OneOfInterfaces = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"thing": {"oneOf": [{"$ref": "#/definitions/a"}, {"$ref": "#/definitions/b"}, {"$ref": "#/definitions/a"}]},
+				"id": {"oneOf": [{"type": "string"}, {"type": "integer"}, {"type": "null"}]}
+			},
+			"definitions": {
+				"a": {"type": "object", "properties": {"x": {"type": "string"}}},
+				"b": {"type": "object", "properties": {"y": {"type": "integer"}}}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then each oneOf should be a marker interface", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type thing interface {\n isThing()\n}")
+				So(compact(src), ShouldContainSubstring, "Thing thing `json:\"thing,omitempty\"`")
+			})
+
+			Convey("Then referenced variants should implement the interface once each", func() {
+				So(compact(src), ShouldContainSubstring, "func (a) isThing() {}")
+				So(compact(src), ShouldContainSubstring, "func (b) isThing() {}")
+				So(strings.Count(src, "func (a) isThing() {}"), ShouldEqual, 1)
+			})
+
+			Convey("Then the package should assert that the variants implement the interface", func() {
+				So(compact(src), ShouldContainSubstring, "var (\n _ thing = (*a)(nil)\n _ thing = (*b)(nil)\n)")
+				So(compact(src), ShouldContainSubstring, "_ id = (*idVariant0)(nil)")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then inline variants should get their own types, except null", func() {
+				So(compact(src), ShouldContainSubstring, "type idVariant0 string")
+				So(compact(src), ShouldContainSubstring, "func (idVariant1) isID() {}")
+				So(compact(src), ShouldNotContainSubstring, "idVariant2")
+			})
+
+			Convey("Then the variants should be assignable to the interface", func() {
+				usage := "package main\n\nvar _ thing = a{}\nvar _ thing = b{}\nvar _ id = idVariant0(\"x\")\n"
+				So(typeCheck(src, usage), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a schema with a oneOf property", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"thing": {"oneOf": [{"$ref": "#/definitions/a"}, {"type": "string"}]}
+			},
+			"definitions": {
+				"a": {"type": "object", "properties": {"x": {"type": "string"}}}
+			}
+		}`
+
+		Convey("When we generate without --oneof-interfaces", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the property should be an empty interface", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Thing interface{} `json:\"thing,omitempty\"`")
+				So(src, ShouldNotContainSubstring, "isThing")
+			})
+
+			Convey("Then the struct should unmarshal", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var s schema
+	err := json.Unmarshal([]byte(` + "`" + `{"thing": {"x": "y"}}` + "`" + `), &s)
+	fmt.Print(err, " ", s.Thing)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "<nil> map[x:y]")
+			})
+		})
+	})
+}
+
+func TestDiscriminatorRegistry(t *testing.T) {
+	Convey("Given a oneOf with a discriminator", t, func() {
+		resetGenerator()
+		opts.OneOfInterfaces = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"pet": {
+					"oneOf": [
+						{"$ref": "#/definitions/dog"},
+						{"$ref": "#/definitions/cat"},
+						{"title": "lizard", "type": "object", "properties": {"petType": {"const": "lizard"}, "length": {"type": "number"}}},
+						{"type": "object", "properties": {"name": {"type": "string"}}}
+					],
+					"discriminator": {"propertyName": "petType", "mapping": {"kitty": "#/definitions/cat", "bird": "#/definitions/bird"}}
+				}
+			},
+			"definitions": {
+				"dog": {"type": "object", "properties": {"petType": {"type": "string"}, "bark": {"type": "string"}}},
+				"cat": {"type": "object", "properties": {"petType": {"type": "string"}, "lives": {"type": "integer"}}},
+				"bird": {"type": "object", "properties": {"wings": {"type": "integer"}}}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			src, err := generateFromString(schema)
+
+			Convey("Then there should be a registry of the variants by discriminator value", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "var petTypeRegistry = map[string]func() pet{\n"+
+					" \"dog\": func() pet { return new(dog) },\n"+
+					" \"kitty\": func() pet { return new(cat) },\n"+
+					" \"lizard\": func() pet { return new(lizard) },\n}")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then the mappings and variants without a value should be left out with a warning", func() {
+				So(logs.String(), ShouldContainSubstring, `Ignoring discriminator mapping of "bird" at #/properties/pet: #/definitions/bird isn't a variant`)
+				So(logs.String(), ShouldContainSubstring, "Leaving petVariant3 out of the discriminator registry of #/properties/pet: it has no discriminator value")
+			})
+
+			Convey("Then a constructor looked up by discriminator value should decode the variant", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	v := petTypeRegistry["kitty"]()
+	err := json.Unmarshal([]byte(` + "`" + `{"petType": "kitty", "lives": 9}` + "`" + `), v)
+	fmt.Printf("%v %#v", err, *v.(*cat))
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, `<nil> main.cat{Lives:9, PetType:"kitty"}`)
+			})
+
+			Convey("Then a struct holding the oneOf should decode its variant by the discriminator", func() {
+				So(src, ShouldContainSubstring, "// UnmarshalJSON decodes schema, using the discriminators of its oneOf fields to pick their variants.\n")
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, doc := range []string{
+		` + "`" + `{"pet": {"petType": "kitty", "lives": 9}}` + "`" + `,
+		` + "`" + `{"pet": {"petType": "dog", "bark": "woof"}}` + "`" + `,
+		` + "`" + `{"pet": null}` + "`" + `,
+		` + "`" + `{"pet": {"petType": "fish"}}` + "`" + `,
+	} {
+		var s schema
+		err := json.Unmarshal([]byte(doc), &s)
+		fmt.Printf("%v %#v\n", err, s.Pet)
+		if err == nil {
+			out, _ := json.Marshal(s)
+			fmt.Println(string(out))
+		}
+	}
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, `<nil> &main.cat{Lives:9, PetType:"kitty"}
+{"pet":{"lives":9,"petType":"kitty"}}
+<nil> &main.dog{Bark:"woof", PetType:"dog"}
+{"pet":{"bark":"woof","petType":"dog"}}
+<nil> <nil>
+{}
+unknown petType "fish" of pet <nil>
+`)
+			})
+		})
+	})
+}
+
+func TestPropertyRefs(t *testing.T) {
+	Convey("Given references to properties and array items instead of definitions", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"alias": {"$ref": "#/properties/name"},
+				"billing": {"$ref": "#/properties/shipping"},
+				"name": {"type": "string"},
+				"shipping": {"type": "object", "properties": {"street": {"type": "string"}}},
+				"tag": {"$ref": "#/properties/tags/items"},
+				"tags": {"type": "array", "items": {"type": "object", "properties": {"key": {"type": "string"}}}},
+				"zip": {"$ref": "#/properties/shipping/properties/street"}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then they should resolve even if the properties come later", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, " Alias name `json:\"alias,omitempty\"`")
+				So(src, ShouldContainSubstring, "type name string")
+				So(compact(src), ShouldContainSubstring, " Billing shipping `json:\"billing,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Shipping shipping `json:\"shipping,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Tag tag `json:\"tag,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Tags []tag `json:\"tags,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Zip street `json:\"zip,omitempty\"`")
+				So(strings.Count(src, "type shipping struct"), ShouldEqual, 1)
+				So(strings.Count(src, "type tag struct"), ShouldEqual, 1)
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestDefs(t *testing.T) {
+	Convey("Given a schema with its definitions under $defs", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"home": {"$ref": "#/$defs/address"},
+				"owner": {"$ref": "#/$defs/person"}
+			},
+			"$defs": {
+				"address": {"type": "object", "properties": {"street": {"type": "string"}}},
+				"person": {"type": "object", "properties": {"name": {"type": "string"}, "address": {"$ref": "#/$defs/address"}}}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the references should resolve to the definitions", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, " Home address `json:\"home,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Owner person `json:\"owner,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Address address `json:\"address,omitempty\"`")
+				So(strings.Count(src, "type address struct"), ShouldEqual, 1)
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a schema with both definitions and $defs", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"a": {"$ref": "#/definitions/old"},
+				"b": {"$ref": "#/$defs/new"}
+			},
+			"definitions": {"old": {"type": "string"}},
+			"$defs": {"new": {"type": "integer"}}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then both should be merged", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type old string")
+				So(src, ShouldContainSubstring, "type new int")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestRecursionStrategy(t *testing.T) {
+	Convey("Given a schema with recursive definitions", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"tree": {"$ref": "#/definitions/node"}
+			},
+			"definitions": {
+				"node": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"parent": {"$ref": "#/definitions/node"},
+						"children": {"type": "array", "items": {"$ref": "#/definitions/node"}},
+						"link": {"$ref": "#/definitions/link"}
+					}
+				},
+				"link": {
+					"type": "object",
+					"properties": {
+						"target": {"$ref": "#/definitions/node"}
+					}
+				}
+			}
+		}`
+
+		Convey("When we generate with the default strategy", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the back-edges should be pointers", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Parent *node")
+				So(compact(src), ShouldContainSubstring, "Target *node")
+			})
+
+			Convey("Then other fields should keep their types", func() {
+				So(compact(src), ShouldContainSubstring, "Children []node")
+				So(compact(src), ShouldContainSubstring, "Link link")
+				So(compact(src), ShouldContainSubstring, "Tree node")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --recursion-strategy=rawmessage", func() {
+			opts.RecursionStrategy = RecursionRawMessage
+			src, err := generateFromString(schema)
+
+			Convey("Then the back-edges should be raw JSON", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "import \"encoding/json\"")
+				So(compact(src), ShouldContainSubstring, "Parent json.RawMessage")
+				So(compact(src), ShouldContainSubstring, "Target json.RawMessage")
+				So(compact(src), ShouldContainSubstring, "Children []node")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given types that refer to themselves directly and through a $ref alias", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"self": {"$ref": "#"},
+				"list": {"$ref": "#/definitions/list"}
+			},
+			"definitions": {
+				"list": {
+					"type": "object",
+					"properties": {
+						"head": {"$ref": "#"},
+						"next": {"$ref": "#/definitions/next"}
+					}
+				},
+				"next": {"$ref": "#/definitions/list"}
+			}
+		}`)
+
+		Convey("Then only the back-edges should be pointers", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "Self *schema")
+			So(compact(src), ShouldContainSubstring, "Head *schema")
+			So(compact(src), ShouldContainSubstring, "Next *list")
+			So(compact(src), ShouldContainSubstring, "List list")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a root type that is referred to but doesn't refer back to itself", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"}
+			},
+			"definitions": {
+				"envelope": {
+					"type": "object",
+					"properties": {
+						"body": {"$ref": "#"}
+					}
+				}
+			}
+		}`)
+
+		Convey("Then references to it should not be pointers", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "Body schema `json:\"body,omitempty\"`")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
+
+func TestOpenAPINullable(t *testing.T) {
+	Convey("Given a schema with OpenAPI nullable properties", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "nullable": true},
+				"count": {"type": ["integer", "null"], "nullable": true},
+				"size": {"type": "integer", "nullable": false},
+				"address": {"$ref": "#/definitions/address", "nullable": true},
+				"level": {"$ref": "#/definitions/level"}
+			},
+			"required": ["name", "count", "size", "address", "level"],
+			"definitions": {
+				"address": {"type": "object", "properties": {"city": {"type": "string"}}},
+				"level": {"type": "integer", "nullable": true}
+			}
+		}`)
+
+		Convey("Then they should be pointers like properties with a null type", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "Name *string `json:\"name\"`")
+			So(compact(src), ShouldContainSubstring, "Count *int `json:\"count\"`")
+			So(compact(src), ShouldContainSubstring, "Size int `json:\"size\"`")
+			So(compact(src), ShouldContainSubstring, "Address *address `json:\"address\"`")
+			So(compact(src), ShouldContainSubstring, "Level *level `json:\"level\"`")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
+
+func TestTypeArrays(t *testing.T) {
+	Convey("Given a schema with type arrays of several shapes", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"name": {"type": ["string"]},
+				"count": {"type": ["null", "integer"]},
+				"id": {"type": ["string", "integer"]},
+				"key": {"type": ["string", "integer", "null"]},
+				"code": {"$ref": "#/definitions/code"},
+				"level": {"$ref": "#/definitions/level"}
+			},
+			"required": ["name", "count", "id", "key", "code", "level"],
+			"definitions": {
+				"code": {"type": ["string", "integer"]},
+				"level": {"type": ["string", "integer", "null"]}
+			}
+		}`)
+
+		Convey("Then single types should keep their Go type", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "Name string `json:\"name\"`")
+			So(compact(src), ShouldContainSubstring, "Count *int `json:\"count\"`")
+		})
+
+		Convey("Then unions of several types should be empty interfaces", func() {
+			So(compact(src), ShouldContainSubstring, "ID interface{} `json:\"id\"`")
+			So(compact(src), ShouldContainSubstring, "Key interface{} `json:\"key\"`")
+			So(src, ShouldContainSubstring, "type code interface{}")
+			So(src, ShouldContainSubstring, "type level interface{}")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
+
+func TestConst(t *testing.T) {
+	Convey("Given a schema with const properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"kind": {"type": "string", "const": "xyz"},
+				"version": {"const": 2},
+				"scale": {"const": 1.5},
+				"enabled": {"const": true},
+				"level": {"$ref": "#/definitions/level"}
+			},
+			"definitions": {
+				"level": {"description": "The only level.", "const": 3}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then each field should note its fixed value", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "// must be \"xyz\"\n Kind string")
+				So(compact(src), ShouldContainSubstring, "// must be true\n Enabled bool")
+			})
+
+			Convey("Then untyped fields should have the narrowest type for their value", func() {
+				So(compact(src), ShouldContainSubstring, "// must be 2\n Version int")
+				So(compact(src), ShouldContainSubstring, "// must be 1.5\n Scale float64")
+			})
+
+			Convey("Then named types should note their fixed value after their description", func() {
+				So(compact(src), ShouldContainSubstring, "// The only level.\n// must be 3\ntype level int")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestExamples(t *testing.T) {
+	Convey("Given a schema with examples", t, func() {
+		resetGenerator()
+		opts.ValidateTags = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"startDate": {"type": "string", "examples": ["2021-01-01", "2022-12-31"]},
+				"code": {"type": "string", "pattern": "^[A-Z]+$", "examples": ["ABC"]},
+				"size": {"const": 3, "examples": [3]},
+				"address": {"type": "object", "examples": [{"city": "Oslo"}], "properties": {"city": {"type": "string"}}},
+				"tags": {"type": "array", "items": {"type": "string"}, "examples": []},
+				"level": {"$ref": "#/definitions/level"}
+			},
+			"definitions": {
+				"level": {"description": "How senior.", "type": "integer", "examples": [2]}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then fields should note their first example", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "// Example: \"2021-01-01\"\n StartDate string")
+				So(src, ShouldNotContainSubstring, "2022-12-31")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then the example should follow the other comments on its own line", func() {
+				So(compact(src), ShouldContainSubstring, "// must match ^[A-Z]+$\n // Example: \"ABC\"\n Code string")
+				So(compact(src), ShouldContainSubstring, "// must be 3\n // Example: 3\n Size int")
+			})
+
+			Convey("Then types should note the example in their own comment", func() {
+				So(compact(src), ShouldContainSubstring, "// Example: {\"city\":\"Oslo\"}\ntype address struct")
+				So(compact(src), ShouldContainSubstring, "// How senior.\n// Example: 2\ntype level int")
+				So(compact(src), ShouldNotContainSubstring, "// Example: {\"city\":\"Oslo\"}\n Address")
+			})
+
+			Convey("Then empty examples should be ignored", func() {
+				So(compact(src), ShouldContainSubstring, "\n Tags []tag")
+				So(compact(src), ShouldNotContainSubstring, "// Example: \n")
+			})
+		})
+	})
+}
+
+func TestReadWriteOnly(t *testing.T) {
+	Convey("Given a schema with read-only and write-only properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "readOnly": true},
+				"password": {"type": "string", "writeOnly": true, "examples": ["hunter2"]},
+				"name": {"type": "string"},
+				"odd": {"type": "string", "readOnly": true, "writeOnly": true},
+				"secret": {"type": "string", "writeOnly": true, "x-go-tags": {"access": "none"}}
+			}
+		}`
+
+		Convey("When we generate without flags", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the fields should have no access comments or tags", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "-only")
+				So(src, ShouldNotContainSubstring, "access:\"read\"")
+			})
+		})
+
+		Convey("When we generate with --access-comments", func() {
+			opts.AccessComments = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the fields should note their access before other comments", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "// read-only\n ID string")
+				So(compact(src), ShouldContainSubstring, "// write-only\n // Example: \"hunter2\"\n Password string")
+				So(compact(src), ShouldContainSubstring, "ID string `json:\"id,omitempty\"`\n Name string")
+				So(compact(src), ShouldContainSubstring, "\n Odd string")
+				So(src, ShouldNotContainSubstring, "access:\"write\"")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --access-tags", func() {
+			opts.AccessTags = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the fields should have access tags unless x-go-tags sets one", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ID string `json:\"id,omitempty\" access:\"read\"`")
+				So(compact(src), ShouldContainSubstring, "Password string `json:\"password,omitempty\" access:\"write\"`")
+				So(compact(src), ShouldContainSubstring, "Secret string `json:\"secret,omitempty\" access:\"none\"`")
+				So(compact(src), ShouldContainSubstring, "Odd string `json:\"odd,omitempty\"`")
+				So(src, ShouldNotContainSubstring, "-only")
+			})
+		})
+	})
+}
+
+func TestDeprecated(t *testing.T) {
+	Convey("Given a schema with deprecated properties and types", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"oldName": {"type": "string", "deprecated": true, "description": "Use name instead."},
+				"legacy": {"type": "integer", "deprecated": true, "const": 1},
+				"name": {"type": "string"},
+				"address": {"$ref": "#/definitions/address", "deprecated": true}
+			},
+			"definitions": {
+				"address": {"type": "object", "description": "A postal address.", "deprecated": true, "properties": {"city": {"type": "string"}}}
+			}
+		}`)
+
+		Convey("Then deprecated fields should have a deprecation paragraph after their description", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "// Use name instead.\n //\n // Deprecated: the schema marks this as deprecated.\n OldName string")
+			So(src, ShouldNotContainSubstring, "Deprecated: Use name instead.")
+			So(compact(src), ShouldContainSubstring, "// must be 1\n //\n // Deprecated: the schema marks this as deprecated.\n Legacy int")
+			So(compact(src), ShouldContainSubstring, "// Deprecated: the schema marks this as deprecated.\n Address address")
+			So(compact(src), ShouldContainSubstring, "`json:\"legacy,omitempty\"`\n Name string")
+		})
+
+		Convey("Then deprecated types should have a deprecation paragraph after their description", func() {
+			So(compact(src), ShouldContainSubstring, "// A postal address.\n//\n// Deprecated: the schema marks this as deprecated.\ntype address struct")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
+
+func TestEnumConstants(t *testing.T) {
+	Convey("Given a schema with string and integer enums", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"status": {"type": "string", "enum": ["active", "inactive", "pending"]},
+				"priority": {"type": ["integer", "null"], "enum": [1, 2, -1]},
+				"ratio": {"type": "number", "enum": [0.5, 1.5]},
+				"stage": {"type": "string", "enum": ["in-progress", "in_progress", "%"]},
+				"size": {"type": "string", "enum": ["1x", "x", "2-x", "1", "Value1"]}
+			},
+			"required": ["status"]
+		}`
+
+		Convey("When we generate for a non-main package", func() {
+			opts.PackageName = "schemas"
+			opts.RootTypeName = "Schema"
+			src, err := generateFromString(schema)
+
+			Convey("Then string enums should have a named string type with a constant per value", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type Status string")
+				So(compact(src), ShouldContainSubstring, `StatusActive Status = "active"`)
+				So(compact(src), ShouldContainSubstring, `StatusPending Status = "pending"`)
+				So(compact(src), ShouldContainSubstring, "Status Status `json:\"status\"`")
+			})
+
+			Convey("Then integer enums should have a named int type, keeping nullability", func() {
+				So(compact(src), ShouldContainSubstring, "type Priority int")
+				So(compact(src), ShouldContainSubstring, "Priority1 Priority = 1")
+				So(compact(src), ShouldContainSubstring, "Priority *Priority")
+			})
+
+			Convey("Then negative values should spell out their sign", func() {
+				So(compact(src), ShouldContainSubstring, "PriorityMinus1 Priority = -1")
+			})
+
+			Convey("Then values with colliding or missing identifiers should fall back to their index", func() {
+				So(compact(src), ShouldContainSubstring, `StageInProgress Stage = "in-progress"`)
+				So(compact(src), ShouldContainSubstring, `StageValue1 Stage = "in_progress"`)
+				So(compact(src), ShouldContainSubstring, `StageValue2 Stage = "%"`)
+			})
+
+			Convey("Then values starting with a digit should be prefixed rather than lose it", func() {
+				So(compact(src), ShouldContainSubstring, `SizeValue1x Size = "1x"`)
+				So(compact(src), ShouldContainSubstring, `SizeX Size = "x"`)
+				So(compact(src), ShouldContainSubstring, `SizeValue2X Size = "2-x"`)
+				So(compact(src), ShouldContainSubstring, `SizeValue1 Size = "1"`)
+				So(compact(src), ShouldContainSubstring, `SizeValue4 Size = "Value1"`)
+			})
+
+			Convey("Then other enums should keep their plain type", func() {
+				So(compact(src), ShouldContainSubstring, "Ratio float64")
+			})
+
+			Convey("Then the output should compile", func() {
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestEnumErrors(t *testing.T) {
+	Convey("Given a schema with an error code enum", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"errorCode": {"type": "string", "enum": ["not_found", "forbidden"]},
+				"status": {"type": "string", "enum": ["ok", "failed"], "x-go-error": true},
+				"color": {"type": "string", "enum": ["red", "green"]}
+			}
+		}`
+
+		Convey("When we generate without --enum-errors", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the enums should have constants but no Error method", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ErrorCode errorCode")
+				So(compact(src), ShouldNotContainSubstring, "Error() string")
+			})
+		})
+
+		Convey("When we generate with --enum-errors", func() {
+			opts.EnumErrors = true
+			src, err := generateFromString(schema)
+
+			Convey("Then an error type with constants should be generated for the error-named enum", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ErrorCode errorCode")
+				So(compact(src), ShouldContainSubstring, `errorCodeNotFound errorCode = "not_found"`)
+				So(compact(src), ShouldContainSubstring, "func (e errorCode) Error() string {")
+			})
+
+			Convey("Then x-go-error should opt an enum in regardless of its name", func() {
+				So(compact(src), ShouldContainSubstring, "func (e status) Error() string {")
+			})
+
+			Convey("Then other enums should not get an Error method", func() {
+				So(compact(src), ShouldNotContainSubstring, "func (e color) Error() string {")
+			})
+
+			Convey("Then the generated constants should satisfy the error interface", func() {
+				usage := "package main\n\nvar _ error = errorCodeNotFound\nvar _ error = statusFailed\n"
+				So(typeCheck(src, usage), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestEnumValidation(t *testing.T) {
+	Convey("Given a schema with enums", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"status": {"type": "string", "enum": ["active", "inactive", "banned"]},
+				"level": {"type": "integer", "enum": [1, 2]},
+				"name": {"type": "string"}
+			}
+		}`
+
+		Convey("When we generate without --enum-validation", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then there should be no IsValid methods", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "IsValid")
+			})
+		})
+
+		Convey("When we generate unexported types with --enum-validation", func() {
+			opts.EnumValidation = true
+			src, err := generateFromString(schema)
+
+			Convey("Then each enum should get an IsValid method and a function listing its values", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "func (e status) IsValid() bool {")
+				So(compact(src), ShouldContainSubstring, "case statusActive, statusInactive, statusBanned:")
+				So(compact(src), ShouldContainSubstring, "func allStatusValues() []status {")
+				So(compact(src), ShouldContainSubstring, "func (e level) IsValid() bool {")
+				So(compact(src), ShouldContainSubstring, "func allLevelValues() []level {")
+				usage := "package main\n\nvar _ = status(\"x\").IsValid() || len(allLevelValues()) == 2\n"
+				So(typeCheck(src, usage), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate exported types with --enum-validation", func() {
+			opts.EnumValidation = true
+			opts.PackageName = "types"
+			src, err := generateFromString(schema)
+
+			Convey("Then the function listing the values should be exported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "func AllStatusValues() []Status {")
+				So(compact(src), ShouldContainSubstring, "return []Status{StatusActive, StatusInactive, StatusBanned}")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestEnumStringer(t *testing.T) {
+	Convey("Given enums with and without names for their values", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"color": {"type": "integer", "enum": [0, 1, 2], "x-enum-varnames": ["Red", "Green", "Blue"]},
+				"size": {"type": "integer", "enum": [1, 2]},
+				"status": {"type": "string", "enum": ["active", "closed"]}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the names should name the constants", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "colorGreen color = 1")
+				So(src, ShouldNotContainSubstring, "String()")
+			})
+		})
+
+		Convey("When we generate with --enum-stringer", func() {
+			opts.EnumStringer = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the enums should have String methods returning the names", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "func (e color) String() string {")
+				So(compact(src), ShouldContainSubstring, "case colorGreen:\n return \"Green\"")
+				So(compact(src), ShouldContainSubstring, "return fmt.Sprintf(\"color(%d)\", int(e))")
+				So(compact(src), ShouldContainSubstring, "case size2:\n return \"2\"")
+				So(compact(src), ShouldContainSubstring, "case statusClosed:\n return \"closed\"\n }\n return string(e)")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then formatting values should use them", func() {
+				out, err := runGenerated(src, `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(colorBlue, color(7), size2, statusActive)
+}
+`)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "Blue color(7) 2 active\n")
+			})
+		})
+	})
+
+	Convey("Given names that don't match the enum's values", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{"type": "integer", "enum": [1, 2], "x-enum-varnames": ["One"]}`)
+
+		Convey("Then they should be ignored", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "schema1 schema = 1")
+			So(compact(src), ShouldContainSubstring, "schema2 schema = 2")
+		})
+	})
+}
+
+func TestFieldSort(t *testing.T) {
+	Convey("Given a schema with required and optional properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"alpha": {"type": "string"},
+				"bravo": {"type": "string"},
+				"charlie": {"type": "string"},
+				"delta": {"type": "string"}
+			},
+			"required": ["delta", "bravo"]
+		}`
+
+		Convey("When we generate with the default field sort", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the fields should be sorted by name", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Alpha string `json:\"alpha,omitempty\"`\n"+
+					" Bravo string `json:\"bravo\"`\n"+
+					" Charlie string `json:\"charlie,omitempty\"`\n"+
+					" Delta string `json:\"delta\"`\n")
+			})
+		})
+
+		Convey("When we generate with --field-sort=required-first", func() {
+			opts.FieldSort = FieldSortRequiredFirst
+			src, err := generateFromString(schema)
+
+			Convey("Then the required fields should come before the optional ones, each sorted by name", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Bravo string `json:\"bravo\"`\n"+
+					" Delta string `json:\"delta\"`\n"+
+					" Alpha string `json:\"alpha,omitempty\"`\n"+
+					" Charlie string `json:\"charlie,omitempty\"`\n")
+			})
+		})
+	})
+}
+
+func TestRedactPasswords(t *testing.T) {
+	Convey("Given a schema with password properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"user": {"type": "string"},
+				"secret": {"type": "string", "format": "password"},
+				"key": {"$ref": "#/definitions/apiKey"}
+			},
+			"definitions": {
+				"apiKey": {"type": "string", "format": "password"}
+			}
+		}`
+
+		Convey("When we generate without --redact-passwords", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then passwords should be plain strings", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Secret string")
+				So(src, ShouldNotContainSubstring, "REDACTED")
+			})
+		})
+
+		Convey("When we generate with --redact-passwords", func() {
+			opts.RedactPasswords = true
+			src, err := generateFromString(schema)
+
+			Convey("Then passwords should use the redacting type", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Secret password")
+				So(compact(src), ShouldContainSubstring, "type password string")
+				So(compact(src), ShouldContainSubstring, "func (apiKey) String() string {")
+			})
+
+			Convey("Then formatting should hide the values but marshalling should not", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	s := schema{User: "bob", Secret: "hunter2", Key: "k3y"}
+	fmt.Printf("%v %+v %#v\n", s, s, s)
+	data, _ := json.Marshal(s)
+	fmt.Println(string(data))
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldContainSubstring, "{[REDACTED] [REDACTED] bob}")
+				So(out, ShouldContainSubstring, "Secret:[REDACTED]")
+				So(out, ShouldContainSubstring, `{"key":"k3y","secret":"hunter2","user":"bob"}`)
+				So(strings.Count(out, "hunter2"), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestAllOf(t *testing.T) {
+	Convey("Given a schema composed with allOf", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"pet": {"$ref": "#/definitions/pet"}
+			},
+			"definitions": {
+				"pet": {
+					"allOf": [
+						{"$ref": "#/definitions/base"},
+						{
+							"type": "object",
+							"properties": {
+								"name": {"type": "string"},
+								"id": {"description": "Unique among pets."},
+								"size": {"type": "string"}
+							},
+							"required": ["name"],
+							"allOf": [{"properties": {"owner": {"type": "string"}}, "required": ["size"]}]
+						}
+					]
+				},
+				"base": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "string"},
+						"size": {"type": "integer"}
+					},
+					"required": ["id"]
+				}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the members' properties should be merged into one struct", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type pet struct {\n"+
+					" ID string `json:\"id\"`\n"+
+					" Name string `json:\"name\"`\n"+
+					" Owner string `json:\"owner,omitempty\"`\n"+
+					" Size interface{} `json:\"size\"`\n"+
+					"}")
+			})
+
+			Convey("Then no types should be generated for inline members", func() {
+				So(src, ShouldNotContainSubstring, "Embedded")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --allof-embed", func() {
+			opts.AllOfEmbed = true
+			src, err := generateFromString(schema)
+
+			Convey("Then referenced members should be embedded and inline members merged", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type pet struct {\n"+
+					" base\n"+
+					" Name string `json:\"name\"`\n"+
+					" Owner string `json:\"owner,omitempty\"`\n"+
+					" Size string `json:\"size\"`\n"+
+					"}")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestFieldTags(t *testing.T) {
+	g := newGenerator(Options{})
+
+	Convey("Given an overflow map field", t, func() {
+		sf := structField{Name: "Extra", PropertyName: "extra", TypePrefix: "map[string]interface{}", Overflow: true}
+
+		Convey("Then its json tag should exclude it from encoding/json", func() {
+			So(g.tags(sf), ShouldEqual, "`json:\"-\"`")
+		})
+	})
+
+	Convey("Given an embedded field", t, func() {
+		sf := structField{TypeRef: "#/definitions/base", Embedded: true}
+
+		Convey("Then it should have no tags", func() {
+			So(g.tags(sf), ShouldEqual, "")
+		})
+	})
+}
+
+func TestPointers(t *testing.T) {
+	Convey("Given a schema with required, optional, and nullable fields", t, func() {
+		resetGenerator()
+		opts.OneOfInterfaces = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "integer"},
+				"count": {"type": "integer"},
+				"note": {"type": ["string", "null"]},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"extra": {},
+				"owner": {"type": "object", "properties": {"name": {"type": "string"}}},
+				"shape": {"oneOf": [{"$ref": "#/definitions/circle"}]}
+			},
+			"required": ["id"],
+			"definitions": {
+				"circle": {"type": "object", "properties": {"radius": {"type": "number"}}}
+			}
+		}`
+
+		Convey("When we generate with the default --pointers=nullable", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then only nullable fields should be pointers", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Count int `")
+				So(compact(src), ShouldContainSubstring, "Note *string `")
+				So(compact(src), ShouldContainSubstring, "Owner owner `")
+			})
+		})
+
+		Convey("When we generate with --pointers=optional", func() {
+			opts.Pointers = PointersOptional
+			src, err := generateFromString(schema)
+
+			Convey("Then fields that aren't required should be pointers", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ID int `")
+				So(compact(src), ShouldContainSubstring, "Count *int `")
+				So(compact(src), ShouldContainSubstring, "Note *string `")
+				So(compact(src), ShouldContainSubstring, "Owner *owner `")
+			})
+
+			Convey("Then slices, maps, and interfaces should not be pointers", func() {
+				So(compact(src), ShouldContainSubstring, "Tags []tag `")
+				So(compact(src), ShouldContainSubstring, "Extra interface{} `")
+				So(compact(src), ShouldContainSubstring, "Shape shape `")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --pointers=none", func() {
+			opts.Pointers = PointersNone
+			src, err := generateFromString(schema)
+
+			Convey("Then nullable fields should not be pointers either", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Note string `")
+				// the assertion that circle implements shape is the only pointer
+				So(strings.Replace(src, "(*circle)(nil)", "", 1), ShouldNotContainSubstring, "*")
+			})
+		})
+	})
+}
+
+func TestTagLibraries(t *testing.T) {
+	Convey("Given a schema with required, optional, and x-go-tags fields", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"port": {"type": "integer"},
+				"path": {"type": "string", "x-go-tags": {"yaml": "file_path"}}
+			},
+			"required": ["name"]
+		}`
+
+		Convey("When we generate with the default tags", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then only json tags should be set", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Port int `json:\"port,omitempty\"`")
+			})
+		})
+
+		Convey("When we generate with --tags=json,yaml", func() {
+			opts.Tags = []string{"json", "yaml"}
+			src, err := generateFromString(schema)
+
+			Convey("Then yaml tags should follow the json tags with the same omitempty", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Name string `json:\"name\" yaml:\"name\"`")
+				So(compact(src), ShouldContainSubstring, "Port int `json:\"port,omitempty\" yaml:\"port,omitempty\"`")
+			})
+
+			Convey("Then an x-go-tags tag should replace the generated one", func() {
+				So(compact(src), ShouldContainSubstring, "Path string `json:\"path,omitempty\" yaml:\"file_path\"`")
+			})
+		})
+	})
+}
+
+func TestBSONTags(t *testing.T) {
+	Convey("Given a schema with required and optional fields", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"userName": {"type": "string"},
+				"Port": {"type": "integer"},
+				"id": {"type": "string", "x-go-tags": {"bson": "_id"}}
+			},
+			"required": ["userName"]
+		}`
+
+		Convey("When we generate with BSONTags", func() {
+			opts.BSONTags = true
+			src, err := generateFromString(schema)
+
+			Convey("Then bson tags with lowercased keys should follow the json tags", func() {
+				So(err, ShouldBeNil)
+				src = compact(src)
+				So(src, ShouldContainSubstring, "UserName string `json:\"userName\" bson:\"username\"`")
+				So(src, ShouldContainSubstring, "Port int `json:\"Port,omitempty\" bson:\"port,omitempty\"`")
+			})
+
+			Convey("Then an x-go-tags bson tag should replace the generated one", func() {
+				So(compact(src), ShouldContainSubstring, "ID string `json:\"id,omitempty\" bson:\"_id\"`")
+			})
+		})
+
+		Convey("When bson is also one of the tag libraries", func() {
+			opts.BSONTags = true
+			opts.Tags = []string{"json", "bson"}
+			src, err := generateFromString(schema)
+
+			Convey("Then there should be one bson tag", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "UserName string `json:\"userName\" bson:\"username\"`")
+			})
+		})
+	})
+
+	Convey("Given a schema whose allOf refers to a definition", t, func() {
+		resetGenerator()
+		opts.AllOfEmbed = true
+		schema := `{
+			"type": "object",
+			"allOf": [{"$ref": "#/definitions/base"}],
+			"properties": {"name": {"type": "string"}},
+			"definitions": {
+				"base": {"type": "object", "properties": {"createdAt": {"type": "string"}}}
+			}
+		}`
+
+		Convey("When we generate with BSONTags", func() {
+			opts.BSONTags = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the embedded type should be inlined in BSON documents", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type schema struct {\n base `bson:\",inline\"`\n")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate without BSONTags", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the embedded type should have no tags", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type schema struct {\n base\n")
+			})
+		})
+	})
+}
+
+func TestNoOmitEmpty(t *testing.T) {
+	Convey("Given a schema with required and optional fields", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {"enabled": {"type": "boolean"}, "count": {"type": "integer"}},
+			"required": ["count"]
+		}`
+
+		Convey("When we generate with --no-omitempty", func() {
+			opts.NoOmitEmpty = true
+			src, err := generateFromString(schema)
+
+			Convey("Then optional fields should not be omitempty", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Enabled bool `json:\"enabled\"`")
+				So(compact(src), ShouldContainSubstring, "Count int `json:\"count\"`")
+				So(src, ShouldNotContainSubstring, "omitempty")
+			})
+		})
+	})
+}
+
+func TestJSONStringOption(t *testing.T) {
+	Convey("Given a schema with numbers and booleans encoded as strings", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"count": {"type": "integer", "x-go-json-string": true},
+				"price": {"type": "number", "format": "string"},
+				"active": {"type": "boolean", "x-go-json-string": true},
+				"level": {"type": "integer", "enum": [1, 2], "x-go-json-string": true},
+				"name": {"type": "string", "x-go-json-string": true}
+			},
+			"required": ["count"]
+		}`
+
+		Convey("When we generate", func() {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			src, err := generateFromString(schema)
+
+			Convey("Then their json tags should have the string option, and their types should be kept", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Count int `json:\"count,string\"`")
+				So(compact(src), ShouldContainSubstring, "Price float64 `json:\"price,omitempty,string\"`")
+				So(compact(src), ShouldContainSubstring, "Active bool `json:\"active,omitempty,string\"`")
+				So(compact(src), ShouldContainSubstring, "Level level `json:\"level,omitempty,string\"`")
+			})
+
+			Convey("Then a string should be left unquoted, with a warning", func() {
+				So(compact(src), ShouldContainSubstring, "Name string `json:\"name,omitempty\"`")
+				So(logs.String(), ShouldContainSubstring, "Ignoring x-go-json-string at #/properties/name")
+			})
+
+			Convey("Then the fields should be decoded from strings", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var s schema
+	err := json.Unmarshal([]byte(` + "`" + `{"count": "3", "price": "1.5", "active": "true", "level": "2"}` + "`" + `), &s)
+	fmt.Println(s.Count, s.Price, s.Active, s.Level, err)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "3 1.5 true 2 <nil>\n")
+			})
+		})
+	})
+}
+
+func TestPruneUnreferenced(t *testing.T) {
+	Convey("Given a schema with referenced and unreferenced definitions", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"address": {"$ref": "#/definitions/address"}
+			},
+			"definitions": {
+				"address": {
+					"type": "object",
+					"properties": {"country": {"$ref": "#/definitions/country"}}
+				},
+				"country": {"type": "string"},
+				"unused": {"type": "object", "properties": {"name": {"type": "string"}}},
+				"kept": {"type": "integer"}
+			}
+		}`
+
+		Convey("When we generate without --prune-unreferenced", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then all definitions should be present", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type unused struct")
+				So(src, ShouldContainSubstring, "type kept int")
+			})
+		})
+
+		Convey("When we generate with --prune-unreferenced", func() {
+			opts.PruneTypes = true
+			src, err := generateFromString(schema)
+
+			Convey("Then unreferenced definitions should be omitted", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "type unused struct")
+				So(src, ShouldNotContainSubstring, "type kept int")
+			})
+
+			Convey("Then directly and transitively referenced definitions should remain", func() {
+				So(src, ShouldContainSubstring, "type schema struct")
+				So(src, ShouldContainSubstring, "type address struct")
+				So(src, ShouldContainSubstring, "type country string")
+			})
+		})
+
+		Convey("When we generate with --prune-unreferenced and --keep", func() {
+			opts.PruneTypes = true
+			opts.KeepTypes = []string{"kept"}
+			src, err := generateFromString(schema)
+
+			Convey("Then the kept definition should remain", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type kept int")
+				So(src, ShouldNotContainSubstring, "type unused struct")
+			})
+		})
+
+		Convey("When we generate with --only", func() {
+			opts.OnlyTypes = []string{"address"}
+			opts.NDJSONDecoder = true
+			src, err := generateFromString(schema)
+
+			Convey("Then only the named types and the types they reference should remain", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type address struct")
+				So(src, ShouldContainSubstring, "type country string")
+				So(src, ShouldNotContainSubstring, "type unused struct")
+				So(src, ShouldNotContainSubstring, "type kept int")
+			})
+
+			Convey("Then the root type and its decoder should be left out", func() {
+				So(src, ShouldNotContainSubstring, "type schema struct")
+				So(src, ShouldNotContainSubstring, "NDJSON")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --only naming the root type", func() {
+			opts.OnlyTypes = []string{"schema", "kept"}
+			src, err := generateFromString(schema)
+
+			Convey("Then the root type should remain with the types it references", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type schema struct")
+				So(src, ShouldContainSubstring, "type address struct")
+				So(src, ShouldContainSubstring, "type kept int")
+				So(src, ShouldNotContainSubstring, "type unused struct")
+			})
+		})
+
+		Convey("When we generate with --only naming a type that doesn't exist", func() {
+			opts.OnlyTypes = []string{"Address"}
+			_, err := generateFromString(schema)
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "no type named Address")
+			})
+		})
+	})
+}
+
+func TestTimeImport(t *testing.T) {
+	Convey("Given a schema whose only date-time is in an unreferenced definition", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {"name": {"type": "string"}},
+			"definitions": {
+				"audit": {"type": "object", "properties": {"at": {"type": "string", "format": "date-time"}}}
+			}
+		}`
+
+		Convey("When we generate without pruning", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then time should be imported", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "import \"time\"")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --prune-unreferenced", func() {
+			opts.PruneTypes = true
+			src, err := generateFromString(schema)
+
+			Convey("Then time should not be imported", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "import")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a schema with a named date-time type", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {"createdAt": {"$ref": "#/definitions/timestamp"}},
+			"definitions": {"timestamp": {"type": "string", "format": "date-time"}}
+		}`
+
+		Convey("When we generate with --prune-unreferenced", func() {
+			opts.PruneTypes = true
+			src, err := generateFromString(schema)
+
+			Convey("Then time should be imported for the named type", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type timestamp time.Time")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestEnumFormat(t *testing.T) {
+	Convey("Given an error enum with format date-time", t, func() {
+		resetGenerator()
+		opts.EnumErrors = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"errorTime": {"type": "string", "format": "date-time", "enum": ["2020-01-01T00:00:00Z"]}
+			},
+			"definitions": {
+				"errorDate": {"type": "string", "format": "date-time", "enum": ["2021-01-01T00:00:00Z"]}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the formatted type should be kept without constants", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ErrorTime time.Time")
+				So(compact(src), ShouldContainSubstring, "type errorDate time.Time\n")
+				So(src, ShouldNotContainSubstring, "const (")
+				So(src, ShouldNotContainSubstring, "Error() string")
+			})
+
+			Convey("Then the output should compile", func() {
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestConstructors(t *testing.T) {
+	Convey("Given a schema with defaults", t, func() {
+		resetGenerator()
+		opts.Constructors = true
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "default": "anonymous"},
+				"retries": {"type": "integer", "default": 3},
+				"ratio": {"type": "number", "default": 0.5},
+				"enabled": {"type": "boolean", "default": true},
+				"nickname": {"type": ["string", "null"], "default": "bob"},
+				"level": {"$ref": "#/definitions/level"},
+				"tags": {"type": "array", "items": {"type": "string"}, "default": ["a"]},
+				"size": {"type": "integer", "default": "big"},
+				"note": {"type": "string"}
+			},
+			"definitions": {
+				"level": {"type": "string", "enum": ["low", "high"], "default": "low"},
+				"empty": {"type": "object", "properties": {"id": {"type": "string"}}}
+			}
+		}`)
+
+		Convey("Then each struct type should have a constructor setting the defaults", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldContainSubstring, "// newSchema returns a schema with the defaults from the schema set.\nfunc newSchema() schema {\n")
+			So(src, ShouldContainSubstring, "\tv.Name = \"anonymous\"\n")
+			So(src, ShouldContainSubstring, "\tv.Retries = 3\n")
+			So(src, ShouldContainSubstring, "\tv.Ratio = 0.5\n")
+			So(src, ShouldContainSubstring, "\tv.Enabled = true\n")
+			So(src, ShouldContainSubstring, "\tv.Nickname = new(string)\n\t*v.Nickname = \"bob\"\n")
+			So(src, ShouldContainSubstring, "func newEmpty() empty {\n\tvar v empty\n\treturn v\n}\n")
+			So(typeCheck(src), ShouldBeNil)
+		})
+
+		Convey("Then defaults that can't be constants, or don't fit their field, should be left out", func() {
+			So(src, ShouldNotContainSubstring, "v.Tags")
+			So(src, ShouldNotContainSubstring, "v.Size")
+			So(src, ShouldNotContainSubstring, "v.Note")
+			So(src, ShouldNotContainSubstring, "v.Level")
+		})
+
+		Convey("Then the constructor should return the defaults", func() {
+			out, err := runGenerated(src, `package main
+
+import "fmt"
+
+func main() {
+	v := newSchema()
+	fmt.Println(v.Name, v.Retries, v.Ratio, v.Enabled, *v.Nickname, v.Note == "")
+}
+`)
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, "anonymous 3 0.5 true bob true\n")
+		})
+	})
+
+	Convey("Given integer defaults outside the range of their types", t, func() {
+		resetGenerator()
+		opts.Constructors = true
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"port": {"type": "integer", "format": "uint32", "default": 4294967295},
+				"offset": {"type": "integer", "format": "int32", "default": -2147483648},
+				"small": {"type": "integer", "format": "int32", "default": 3000000000},
+				"unsigned": {"type": "integer", "format": "uint32", "default": 4294967296},
+				"huge": {"type": "integer", "default": 1e19},
+				"wide": {"type": "integer", "format": "int64", "default": -1e19},
+				"total": {"type": "integer", "format": "uint64", "default": 2e19}
+			}
+		}`)
+
+		Convey("Then only the defaults that fit should be set", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldContainSubstring, "\tv.Port = 4294967295\n")
+			So(src, ShouldContainSubstring, "\tv.Offset = -2147483648\n")
+			So(src, ShouldNotContainSubstring, "v.Small")
+			So(src, ShouldNotContainSubstring, "v.Unsigned")
+			So(src, ShouldNotContainSubstring, "v.Huge")
+			So(src, ShouldNotContainSubstring, "v.Wide")
+			So(src, ShouldNotContainSubstring, "v.Total")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
+
+func TestGenBuilder(t *testing.T) {
+	Convey("Given a schema with required and optional properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string"},
+				"type": {"type": "string"},
+				"count": {"type": "integer", "default": 10},
+				"labels": {"type": "array", "items": {"type": "string"}},
+				"tags": {"type": "array", "items": {"type": "string"}}
+			},
+			"required": ["id", "type", "tags"]
+		}`
+
+		Convey("When we generate with --gen-builder", func() {
+			opts.GenBuilders = true
+			src, err := generateFromString(schema)
+
+			Convey("Then a builder should be generated taking the required fields in its constructor", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "func newSchemaBuilder(id string, tags []tag, type_ string) *schemaBuilder {")
+				So(src, ShouldContainSubstring, "func (b *schemaBuilder) WithCount(v int) *schemaBuilder {")
+				So(src, ShouldContainSubstring, "func (b *schemaBuilder) Build() (schema, error) {")
+			})
+
+			Convey("Then the builder should build an instance fluently", func() {
+				mainSrc := `package main
+
+import "fmt"
+
+func main() {
+	s, err := newSchemaBuilder("abc", []tag{"t"}, "widget").WithCount(3).WithLabels([]label{"x"}).Build()
+	fmt.Printf("%s %s %d %v %v\n", s.ID, s.Type, s.Count, s.Labels, err)
+	s, err = newSchemaBuilder("abc", []tag{"t"}, "widget").Build()
+	fmt.Printf("%d %v\n", s.Count, err)
+	_, err = newSchemaBuilder("abc", nil, "widget").Build()
+	fmt.Println(err)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "abc widget 3 [x] <nil>\n10 <nil>\nschema: required property \"tags\" is nil\n")
+			})
+		})
+	})
+}
+
+func TestOrderedMapType(t *testing.T) {
+	Convey("Given a schema with additionalProperties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"scores": {"type": "object", "additionalProperties": {"type": "integer"}}
+			}
+		}`
+
+		Convey("When we generate with the default --map-type", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the field should be a map", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Scores map[string]score")
+				So(src, ShouldNotContainSubstring, "orderedMap")
+			})
+		})
+
+		Convey("When we generate with --map-type=ordered", func() {
+			opts.MapType = MapTypeOrdered
+			src, err := generateFromString(schema)
+
+			Convey("Then the field should be an ordered map", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Scores orderedMap[score]")
+				So(src, ShouldContainSubstring, "type orderedMap[V any] struct {")
+			})
+
+			Convey("Then key order should be preserved on round-trip", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var s schema
+	if err := json.Unmarshal([]byte(` + "`" + `{"scores":{"zed":1,"alpha":2,"mid":3}}` + "`" + `), &s); err != nil {
+		panic(err)
+	}
+	s.Scores.Set("last", 4)
+	out, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(out))
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, `{"scores":{"zed":1,"alpha":2,"mid":3,"last":4}}`)
+			})
+		})
+	})
+}
+
+func TestEnumMarshalCheck(t *testing.T) {
+	Convey("Given a schema with an enum", t, func() {
+		resetGenerator()
+		opts.EnumErrors = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"errorCode": {"type": "string", "enum": ["not_found", "forbidden"]}
+			}
+		}`
+
+		Convey("When we generate without --enum-marshal-check", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then no MarshalJSON method should be generated", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "MarshalJSON")
+			})
+		})
+
+		Convey("When we generate with --enum-marshal-check", func() {
+			opts.EnumMarshalCheck = true
+			src, err := generateFromString(schema)
+
+			Convey("Then a MarshalJSON method should be generated", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "func (e errorCode) MarshalJSON() ([]byte, error) {")
+			})
+
+			Convey("Then marshalling a known value should succeed and an unknown value should fail", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	out, err := json.Marshal(schema{ErrorCode: errorCodeForbidden})
+	fmt.Println(string(out), err)
+	_, err = json.Marshal(schema{ErrorCode: "teapot"})
+	fmt.Print(err != nil)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "{\"errorCode\":\"forbidden\"} <nil>\ntrue")
+			})
+		})
+	})
+}
+
+func TestUnexportPattern(t *testing.T) {
+	Convey("Given a schema with an internal property", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"_internal": {"type": "string"},
+				"_type": {"type": "integer"}
+			}
+		}`
+
+		Convey("When we generate with --unexport-pattern", func() {
+			opts.UnexportPattern = regexp.MustCompile("^_")
+			src, err := generateFromString(schema)
+
+			Convey("Then matching properties should be unexported fields without tags", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, " internal string\n")
+				So(compact(src), ShouldContainSubstring, " type_ int\n")
+				So(compact(src), ShouldContainSubstring, "Name string `json:\"name,omitempty\"`")
+			})
+
+			Convey("Then the tags should be on the fields of the codec's wrapper", func() {
+				So(compact(src), ShouldContainSubstring, "Internal string `json:\"_internal,omitempty\"`")
+			})
+
+			Convey("Then go vet should pass on the generated code", func() {
+				opts.Tags = []string{"json", "yaml"}
+				opts.BSONTags = true
+				src, err := generateFromString(schema)
+				So(err, ShouldBeNil)
+				out, err := vetGenerated(src)
+				So(out, ShouldBeEmpty)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then the generated codec should round-trip the unexported fields", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var s schema
+	if err := json.Unmarshal([]byte(` + "`" + `{"name":"n","_internal":"secret","_type":2}` + "`" + `), &s); err != nil {
+		panic(err)
+	}
+	fmt.Println(s.Name, s.internal, s.type_)
+	out, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(out))
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "n secret 2\n{\"name\":\"n\",\"_internal\":\"secret\",\"_type\":2}")
+			})
+		})
+
+		Convey("When we generate without --unexport-pattern", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then all fields should be exported without extra methods", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Internal string `json:\"_internal,omitempty\"`")
+				So(src, ShouldNotContainSubstring, "MarshalJSON")
+			})
+		})
+	})
+}
+
+func TestDuplicateFieldNames(t *testing.T) {
+	Convey("Given properties whose field names collide", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"name$": {"type": "integer"},
+				"name-": {"type": "boolean"}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the later fields should get a numeric suffix and keep their json tags", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Name string `json:\"name,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "Name2 int `json:\"name$,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "Name3 bool `json:\"name-,omitempty\"`")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestDeterministicTypeNames(t *testing.T) {
+	Convey("Given nested types whose names collide", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"home": {"type": "object", "properties": {"address": {"type": "object", "properties": {"city": {"type": "string"}}}}},
+				"work": {"type": "object", "properties": {"address": {"type": "object", "properties": {"street": {"type": "string"}}}}},
+				"address": {"type": "object", "properties": {"zip": {"type": "string"}}},
+				"billing": {"type": "object", "properties": {"home": {"type": "object", "properties": {"address": {"type": "object", "properties": {"country": {"type": "string"}}}}}}}
+			}
+		}`
+
+		Convey("When we generate repeatedly", func() {
+			first, err := generateFromString(schema)
+			So(err, ShouldBeNil)
+
+			Convey("Then the disambiguated names should be the same every time", func() {
+				for i := 0; i < 20; i++ {
+					src, err := generateFromString(schema)
+					So(err, ShouldBeNil)
+					So(src, ShouldEqual, first)
+				}
+				So(first, ShouldContainSubstring, "type schemaHomeAddress struct")
+				So(first, ShouldContainSubstring, "type billingHomeAddress struct")
+				So(first, ShouldContainSubstring, "type workAddress struct")
+				So(typeCheck(first), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given definitions whose names collide with properties", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"properties": {"type": "object", "properties": {"x": {"type": "string"}}},
+				"counts": {"$ref": "#/definitions/properties"},
+				"user": {"type": "object", "properties": {"address": {"type": "object", "properties": {"street": {"type": "string"}}}}}
+			},
+			"definitions": {
+				"properties": {"type": "object", "additionalProperties": {"type": "integer"}},
+				"address": {"type": "object", "properties": {"zip": {"type": "string"}}}
+			}
+		}`)
+
+		Convey("Then the definitions should keep their names", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldContainSubstring, "type properties map[string]property")
+			So(src, ShouldContainSubstring, "type address struct")
+			So(compact(src), ShouldContainSubstring, " Properties schemaProperties `json:\"properties,omitempty\"`")
+			So(compact(src), ShouldContainSubstring, " Address userAddress `json:\"address,omitempty\"`")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
+
+func TestUnexportedTypes(t *testing.T) {
+	Convey("Given a schema for a package other than main", t, func() {
+		resetGenerator()
+		opts.RootTypeName = ""
+		opts.PackageName = "internal"
+		opts.Unexported = true
+		opts.EmitPtrHelpers = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"userId": {"type": "string"},
+				"status": {"type": "string", "enum": ["active"]},
+				"address": {"type": "object", "properties": {"city": {"type": "string"}}}
+			}
+		}`
+
+		Convey("When we generate with Unexported", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the types and helpers should be unexported and the fields exported", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type schema struct {")
+				So(src, ShouldContainSubstring, "type address struct {")
+				So(compact(src), ShouldContainSubstring, "statusActive status = \"active\"")
+				So(compact(src), ShouldContainSubstring, "UserID string")
+				So(src, ShouldContainSubstring, "func ptr[")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with Unexported and a prefix", func() {
+			opts.TypeNamesPrefix = "API"
+			src, err := generateFromString(schema)
+
+			Convey("Then the prefix should start the unexported names", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type apiAddress struct {")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestKeywordIdentifiers(t *testing.T) {
+	Convey("Given a schema with types named like Go keywords", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"type": {"type": "object", "properties": {"name": {"type": "string"}}},
+				"range": {"$ref": "#/definitions/range"},
+				"func": {"type": "string"}
+			},
+			"definitions": {
+				"range": {"type": "array", "items": {"type": "integer"}}
+			}
+		}`
+
+		Convey("When we generate unexported types", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then keyword names should get a suffix", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type type_ struct {")
+				So(compact(src), ShouldContainSubstring, "type range_ []rangeItem")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then exported field names should be unchanged", func() {
+				So(compact(src), ShouldContainSubstring, "Type type_ `json:\"type,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "Func string `json:\"func,omitempty\"`")
+			})
+		})
+	})
+}
+
+func TestAvoidBuiltinShadow(t *testing.T) {
+	Convey("Given a schema with a definition titled Error", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"failure": {"$ref": "#/definitions/failure"}
+			},
+			"definitions": {
+				"failure": {
+					"title": "Error",
+					"type": "object",
+					"properties": {"message": {"type": "string"}}
+				}
+			}
+		}`
+
+		Convey("When we generate without --avoid-builtin-shadow", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the type should shadow the builtin", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type error struct {")
+			})
+		})
+
+		Convey("When we generate with --avoid-builtin-shadow", func() {
+			opts.AvoidBuiltinShadow = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the type should be renamed", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type errorType struct {")
+				So(compact(src), ShouldContainSubstring, "Failure errorType `json:\"failure,omitempty\"`")
+			})
+		})
+
+		Convey("When we generate exported types with --avoid-builtin-shadow", func() {
+			opts.AvoidBuiltinShadow = true
+			opts.PackageName = "schemas"
+			opts.RootTypeName = "Schema"
+			src, err := generateFromString(schema)
+
+			Convey("Then the type should keep its name, which shadows nothing", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type Error struct {")
+			})
+		})
+	})
+
+	Convey("Given a schema with definitions titled after Go keywords", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"lookup": {"$ref": "#/definitions/lookup"},
+				"callback": {"$ref": "#/definitions/callback"}
+			},
+			"definitions": {
+				"lookup": {
+					"title": "Map",
+					"type": "object",
+					"properties": {"key": {"type": "string"}}
+				},
+				"callback": {
+					"title": "Func",
+					"type": "string"
+				}
+			}
+		}`
+
+		Convey("When we generate with --avoid-builtin-shadow", func() {
+			opts.AvoidBuiltinShadow = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the types should be renamed", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type mapType struct {")
+				So(src, ShouldContainSubstring, "type funcType string")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a schema with definitions titled after predeclared functions", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"length": {"$ref": "#/definitions/length"},
+				"minimum": {"$ref": "#/definitions/minimum"}
+			},
+			"definitions": {
+				"length": {"title": "Len", "type": "integer"},
+				"minimum": {"title": "Min", "type": "number"}
+			}
+		}`
+
+		Convey("When we generate exported types with --avoid-builtin-shadow", func() {
+			opts.AvoidBuiltinShadow = true
+			opts.PackageName = "schemas"
+			opts.RootTypeName = "Schema"
+			src, err := generateFromString(schema)
+
+			Convey("Then the types should keep their names", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type Len int")
+				So(src, ShouldContainSubstring, "type Min float64")
+				So(src, ShouldNotContainSubstring, "LenType")
+				So(src, ShouldNotContainSubstring, "MinType")
+			})
+		})
+
+		Convey("When we generate unexported types with --avoid-builtin-shadow", func() {
+			opts.AvoidBuiltinShadow = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the types should be renamed", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type lenType int")
+				So(src, ShouldContainSubstring, "type minType float64")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestMaxNameLength(t *testing.T) {
+	Convey("Given deeply nested types whose names collide", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"config": {"type": "object", "properties": {
+					"server": {"type": "object", "properties": {"tls": {"type": "object", "properties": {
+						"certificateAuthority": {"type": "object", "properties": {"path": {"type": "string"}}}
+					}}}},
+					"client": {"type": "object", "properties": {"tls": {"type": "object", "properties": {
+						"certificateAuthority": {"type": "object", "properties": {"url": {"type": "string"}}}
+					}}}}
+				}}
+			}
+		}`
+		typeNames := regexp.MustCompile(`(?m)^type (\w+) `)
+
+		Convey("When we generate with --max-name-length", func() {
+			opts.MaxNameLength = 16
+			src, err := generateFromString(schema)
+			So(err, ShouldBeNil)
+			again, err := generateFromString(schema)
+			So(err, ShouldBeNil)
+
+			Convey("Then the names should be capped and stay unique", func() {
+				names := map[string]bool{}
+				for _, m := range typeNames.FindAllStringSubmatch(src, -1) {
+					So(len(m[1]), ShouldBeLessThanOrEqualTo, 16)
+					So(names[m[1]], ShouldBeFalse)
+					names[m[1]] = true
+				}
+				So(len(names), ShouldEqual, 8)
+				So(names["config"], ShouldBeTrue)
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then the abbreviations should be the same on every run", func() {
+				So(again, ShouldEqual, src)
+			})
+		})
+
+		Convey("When we generate without it", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the disambiguated names should be longer", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "type serverTLSCertificateAuthority struct")
+			})
+		})
+
+		Convey("When we generate with a length that leaves no room for the hash", func() {
+			opts.MaxNameLength = 6
+			_, err := generateFromString(schema)
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestGenSQL(t *testing.T) {
+	Convey("Given a schema with named scalar types", t, func() {
+		resetGenerator()
+		opts.EnumErrors = true
+		schema := `{
+			"type": "object",
+			"properties": {
+				"id": {"$ref": "#/definitions/id"},
+				"errorCode": {"type": "string", "enum": ["not_found", "forbidden"]}
+			},
+			"definitions": {
+				"id": {"type": "integer"}
+			}
+		}`
+
+		Convey("When we generate with --gen-sql", func() {
+			opts.GenSQL = true
+			src, err := generateFromString(schema)
+
+			Convey("Then Scan and Value methods should be generated", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "func (v *id) Scan(src interface{}) error {")
+				So(src, ShouldContainSubstring, "func (v errorCode) Value() (driver.Value, error) {")
+				So(src, ShouldNotContainSubstring, "func (v *schema) Scan")
+			})
+
+			Convey("Then the types should satisfy sql.Scanner and driver.Valuer", func() {
+				usage := `package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+var (
+	_ sql.Scanner   = (*id)(nil)
+	_ driver.Valuer = id(0)
+	_ sql.Scanner   = (*errorCode)(nil)
+	_ driver.Valuer = errorCode("")
+)
+`
+				So(typeCheck(src, usage), ShouldBeNil)
+			})
+
+			Convey("Then scanning should validate enum values", func() {
+				mainSrc := `package main
+
+import "fmt"
+
+func main() {
+	var code errorCode
+	fmt.Println(code.Scan([]byte("forbidden")), code)
+	fmt.Println(code.Scan("teapot") != nil, code)
+	var i id
+	fmt.Print(i.Scan(int64(7)), i)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "<nil> forbidden\ntrue forbidden\n<nil> 7")
+			})
+		})
+	})
+}
+
+func TestNDJSONDecoder(t *testing.T) {
+	Convey("Given a schema for an array of records", t, func() {
+		resetGenerator()
+		opts.RootTypeName = "events"
+		schema := `{
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "integer"},
+					"kind": {"type": "string"}
+				}
+			}
+		}`
+
+		Convey("When we generate with --root-type-is-slice-alias", func() {
+			opts.NDJSONDecoder = true
+			src, err := generateFromString(schema)
+
+			Convey("Then a decoder returning the root slice type should be generated", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "func decodeEventsNDJSON(r io.Reader) (events, error) {")
+			})
+
+			Convey("Then the decoder should decode one element per line", func() {
+				mainSrc := `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	events, err := decodeEventsNDJSON(strings.NewReader("{\"id\":1,\"kind\":\"a\"}\n{\"id\":2,\"kind\":\"b\"}\n\n{\"id\":3}\n"))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(len(events), events[0].Kind, events[1].ID, events[2].ID)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "3a2 3")
+			})
+		})
+	})
+
+	Convey("Given a schema for a single record", t, func() {
+		resetGenerator()
+		opts.RootTypeName = "Event"
+		opts.PackageName = "events"
+		schema := `{"type": "object", "properties": {"id": {"type": "integer"}}}`
+
+		Convey("When we generate with --root-type-is-slice-alias", func() {
+			opts.NDJSONDecoder = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the decoder should return a slice of the root type", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "func DecodeEventNDJSON(r io.Reader) ([]Event, error) {")
+			})
+		})
+	})
+}
+
+func TestValidateTags(t *testing.T) {
+	Convey("Given a schema with constraints", t, func() {
+		resetGenerator()
+		schema := `{"type": "object", "required": ["name"], "properties": {
+			"name": {"type": "string", "minLength": 3, "maxLength": 50, "pattern": "^[a-z]+$"},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"score": {"type": ["number", "null"], "minimum": 0, "exclusiveMinimum": true, "maximum": 1.5},
+			"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+			"nickname": {"type": "string"}
+		}}`
+
+		Convey("When we generate without --validate-tags", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then there should be no validate tags or pattern comments", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "validate:")
+				So(src, ShouldNotContainSubstring, "must match")
+			})
+		})
+
+		Convey("When we generate with --validate-tags", func() {
+			opts.ValidateTags = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the constraints should become validate tags", func() {
+				So(err, ShouldBeNil)
+				src = compact(src)
+				So(src, ShouldContainSubstring, "Age int `json:\"age,omitempty\" validate:\"omitempty,min=0,max=150\"`")
+				So(src, ShouldContainSubstring, "// must match ^[a-z]+$\n Name string `json:\"name\" validate:\"min=3,max=50\"`")
+				So(src, ShouldContainSubstring, "Nickname string `json:\"nickname,omitempty\"`")
+				So(src, ShouldContainSubstring, "Score *float64 `json:\"score,omitempty\" validate:\"omitempty,gt=0,max=1.5\"`")
+				So(src, ShouldContainSubstring, "Tags []tag `json:\"tags,omitempty\" validate:\"omitempty,min=1\"`")
+			})
+		})
+
+		Convey("When x-go-tags sets a validate tag", func() {
+			opts.ValidateTags = true
+			src, err := generateFromString(`{"type": "object", "properties": {
+				"name": {"type": "string", "minLength": 3, "x-go-tags": {"validate": "alpha"}}
+			}}`)
+
+			Convey("Then it should replace the generated one", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Name string `json:\"name,omitempty\" validate:\"alpha\"`")
+			})
+		})
+	})
+}
+
+func TestPreserveOrder(t *testing.T) {
+	Convey("Given a schema whose properties aren't in alphabetical order", t, func() {
+		resetGenerator()
+		schema := `{"type": "object", "properties": {
+			"zeta": {"type": "string"},
+			"alpha": {"type": "object", "properties": {"second": {"type": "string"}, "first": {"type": "string"}}},
+			"mid": {"type": "integer"}
+		}, "allOf": [{"properties": {"extra": {"type": "string"}, "another": {"type": "string"}}}]}`
+
+		Convey("When we generate with the default field sort", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then fields should be sorted by name", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "type schema struct {\n Alpha alpha `json:\"alpha,omitempty\"`\n Another string `json:\"another,omitempty\"`\n Extra string")
+			})
+		})
+
+		Convey("When we generate with FieldSortSchema", func() {
+			opts.FieldSort = FieldSortSchema
+			src, err := generateFromString(schema)
+
+			Convey("Then fields should be in schema order, with allOf members' properties after the type's own", func() {
+				So(err, ShouldBeNil)
+				src = compact(src)
+				So(src, ShouldContainSubstring, "type schema struct {\n Zeta string `json:\"zeta,omitempty\"`\n Alpha alpha `json:\"alpha,omitempty\"`\n Mid int `json:\"mid,omitempty\"`\n Extra string `json:\"extra,omitempty\"`\n Another string `json:\"another,omitempty\"`\n}")
+				So(src, ShouldContainSubstring, "type alpha struct {\n Second string `json:\"second,omitempty\"`\n First string `json:\"first,omitempty\"`\n}")
+			})
+		})
+
+		Convey("When we generate with FieldSortSchemaOrder", func() {
+			opts.FieldSort = FieldSortSchema
+			schemaSrc, err := generateFromString(schema)
+			So(err, ShouldBeNil)
+			opts.FieldSort = FieldSortSchemaOrder
+			src, err := generateFromString(schema)
+
+			Convey("Then fields should be in schema order too", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldEqual, schemaSrc)
+			})
+		})
+	})
+}
+
+func TestBooleanItems(t *testing.T) {
+	Convey("Given arrays whose items are boolean schemas", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"anything": {"type": "array", "items": true},
+				"nothing": {"type": "array", "items": false, "description": "Reserved."},
+				"empty": {"$ref": "#/definitions/empty"}
+			},
+			"definitions": {
+				"empty": {"type": "array", "items": false}
+			}
+		}`)
+
+		Convey("Then they should be slices of empty interfaces, noting when they must be empty", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, " Anything []interface{}")
+			So(compact(src), ShouldContainSubstring, " // Reserved.\n // must be empty\n Nothing []interface{}")
+			So(src, ShouldContainSubstring, "// must be empty\ntype empty []interface{}")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
+
+func TestPrefixItems(t *testing.T) {
+	Convey("Given the same tuples as draft-04 items arrays and draft 2020-12 prefixItems", t, func() {
+		schema := `{
+			"type": "object",
+			"properties": {
+				"pair": {"type": "array", "items": [{"type": "string"}, {"$ref": "#/definitions/point"}]},
+				"single": {"type": "array", "items": [{"type": "integer"}]},
+				"line": {"$ref": "#/definitions/line"}
+			},
+			"definitions": {
+				"point": {"type": "object", "properties": {"x": {"type": "number"}}},
+				"line": {"type": "array", "items": [{"$ref": "#/definitions/point"}, {"$ref": "#/definitions/point"}, {}]}
+			}
+		}`
+
+		resetGenerator()
+		itemsSrc, itemsErr := generateFromString(schema)
+		resetGenerator()
+		prefixSrc, prefixErr := generateFromString(strings.Replace(schema, `"items"`, `"prefixItems"`, -1))
+
+		Convey("Then they should generate the same code, noting the types of the tuple items", func() {
+			So(itemsErr, ShouldBeNil)
+			So(prefixErr, ShouldBeNil)
+			So(prefixSrc, ShouldEqual, itemsSrc)
+			So(compact(prefixSrc), ShouldContainSubstring, " // tuple of string, point\n Pair []interface{}")
+			So(compact(prefixSrc), ShouldContainSubstring, " Single []singleItem")
+			So(prefixSrc, ShouldContainSubstring, "// tuple of point, point, any\ntype line []interface{}")
+			So(typeCheck(prefixSrc), ShouldBeNil)
+		})
+	})
+}
+
+func TestArrayItemTitles(t *testing.T) {
+	Convey("Given arrays whose items have titles", t, func() {
+		resetGenerator()
+		schema := `{"type": "object", "properties": {
+			"things": {"type": "array", "items": {"title": "Widget", "type": "object", "properties": {"a": {"type": "string"}}}},
+			"list": {"type": "array", "items": [{"title": "Gadget", "type": "string"}]},
+			"others": {"type": "array", "items": {"type": "object", "properties": {"b": {"type": "string"}}}}
+		}, "definitions": {
+			"collection": {"type": "array", "items": {"title": "Entry", "type": "object", "properties": {"c": {"type": "string"}}}}
+		}}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the item types should be named by their titles instead of the singularized array name", func() {
+				So(err, ShouldBeNil)
+				src = compact(src)
+				So(src, ShouldContainSubstring, "Things []widget `json:\"things,omitempty\"`")
+				So(src, ShouldContainSubstring, "List []gadget `json:\"list,omitempty\"`")
+				So(src, ShouldContainSubstring, "Others []other `json:\"others,omitempty\"`")
+				So(src, ShouldContainSubstring, "type collection []entry")
+				So(src, ShouldNotContainSubstring, "thing struct")
+			})
+		})
+	})
+}
+
+func TestPointerEscaping(t *testing.T) {
+	Convey("Given a schema with definitions and properties whose names contain / and ~", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"home/address": {"$ref": "#/definitions/postal~1address"},
+				"owner": {"$ref": "#/definitions/user~0v2"},
+				"paths": {"type": "object", "patternProperties": {"^/api/": {"$ref": "#/definitions/postal~1address"}}}
+			},
+			"definitions": {
+				"postal/address": {"type": "object", "properties": {"street": {"type": "string"}}},
+				"user~v2": {"type": "object", "properties": {"a/b": {"type": "object", "properties": {"c": {"type": "string"}}}}}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the escaped references should resolve to the types", func() {
+				So(err, ShouldBeNil)
+				src = compact(src)
+				So(src, ShouldContainSubstring, "HomeAddress postaladdress `json:\"home/address,omitempty\"`")
+				So(src, ShouldContainSubstring, "Owner userv2 `json:\"owner,omitempty\"`")
+				So(src, ShouldContainSubstring, "Paths map[string]postaladdress `json:\"paths,omitempty\"`")
+				So(src, ShouldContainSubstring, "AB ab `json:\"a/b,omitempty\"`")
+			})
+		})
+	})
+}
+
+func TestNoFormat(t *testing.T) {
+	Convey("Given a schema", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"createdAt": {"type": "string", "format": "date-time"}
+			}
+		}`
+
+		Convey("When we generate with NoFormat", func() {
+			opts.NoFormat = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the source should be returned without formatting", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "import \"time\"\n")
+				So(src, ShouldContainSubstring, "type schema struct {\nCreatedAt time.Time `json:\"createdAt,omitempty\"`\nName string")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given source that can't be formatted", t, func() {
+		src := []byte("package p\n\ntype t struct {\n\tA int `a`b`\n}\n")
+		_, err := format.Source(src)
+
+		Convey("Then the error should show the line it points to", func() {
+			err = formatError(src, err)
+			So(err.Error(), ShouldStartWith, "4:")
+			So(err.Error(), ShouldEndWith, "\nline 4: A int `a`b`")
+		})
+	})
+}
+
+func TestJSONTagCase(t *testing.T) {
+	Convey("Given properties named in different cases", t, func() {
+		schema := `{"type": "object", "properties": {
+			"userID": {"type": "string"},
+			"first-name": {"type": "string"},
+			"last_name": {"type": "string"},
+			"Age": {"type": "integer"}
+		}}`
+
+		Convey("When we generate with the default tag case", func() {
+			resetGenerator()
+			src, err := generateFromString(schema)
+
+			Convey("Then the tags should keep the property names", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "`json:\"userID,omitempty\"`")
+				So(src, ShouldContainSubstring, "`json:\"first-name,omitempty\"`")
+				So(src, ShouldContainSubstring, "`json:\"last_name,omitempty\"`")
+				So(src, ShouldContainSubstring, "`json:\"Age,omitempty\"`")
+			})
+		})
+
+		Convey("When we generate with snake_case tags", func() {
+			resetGenerator()
+			opts.JSONTagCase = JSONTagCaseSnake
+			src, err := generateFromString(schema)
+
+			Convey("Then the tags should be in snake_case, and the field names unchanged", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, " UserID string `json:\"user_id,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " FirstName string `json:\"first_name,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " LastName string `json:\"last_name,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Age int `json:\"age,omitempty\"`")
+			})
+		})
+
+		Convey("When we generate with camelCase tags", func() {
+			resetGenerator()
+			opts.JSONTagCase = JSONTagCaseCamel
+			src, err := generateFromString(schema)
+
+			Convey("Then the tags should be in camelCase", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "`json:\"userId,omitempty\"`")
+				So(src, ShouldContainSubstring, "`json:\"firstName,omitempty\"`")
+				So(src, ShouldContainSubstring, "`json:\"lastName,omitempty\"`")
+				So(src, ShouldContainSubstring, "`json:\"age,omitempty\"`")
+			})
+		})
+	})
+}
+
+func TestNoComments(t *testing.T) {
+	Convey("Given a schema with descriptions", t, func() {
+		schema := `{
+			"type": "object",
+			"description": "A user.",
+			"properties": {
+				"name": {"type": "string", "description": "The user's name."},
+				"status": {"type": "string", "enum": ["active"], "description": "The user's status."}
+			}
+		}`
+
+		Convey("When we generate with NoComments and NoBanner", func() {
+			resetGenerator()
+			opts.NoComments = true
+			opts.NoBanner = true
+			opts.GenBuilders = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the types and fields should have no comments, and there should be no banner", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "A user.")
+				So(src, ShouldNotContainSubstring, "The user's name.")
+				So(src, ShouldNotContainSubstring, "The user's status.")
+				So(src, ShouldNotContainSubstring, "DO NOT EDIT")
+				So(src, ShouldStartWith, "package main\n\ntype schema struct {")
+			})
+
+			Convey("Then generated methods should keep their comments", func() {
+				So(src, ShouldContainSubstring, "// schemaBuilder builds a schema.\n")
+			})
+		})
+
+		Convey("When we generate with NoComments alone", func() {
+			resetGenerator()
+			opts.NoComments = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the banner should be kept", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "DO NOT EDIT.\n")
+				So(src, ShouldNotContainSubstring, "A user.")
+			})
+		})
+	})
+}
+
+func TestUseAny(t *testing.T) {
+	Convey("Given a schema with untyped values", t, func() {
+		schema := `{
+			"type": "object",
+			"properties": {
+				"anything": {},
+				"choice": {"type": ["string", "integer", "null"]},
+				"list": {"type": "array"},
+				"meta": {"type": "object"},
+				"extra": {"type": "object", "properties": {"a": {"type": "string"}}, "additionalProperties": true}
+			}
+		}`
+
+		Convey("When we generate with UseAny", func() {
+			resetGenerator()
+			opts.UseAny = true
+			src, err := generateFromString(schema)
+
+			Convey("Then the empty interface should be written as any", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldNotContainSubstring, "interface{}")
+				So(compact(src), ShouldContainSubstring, " Anything any `json:\"anything,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Choice any `json:\"choice,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " List []any `json:\"list,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " Meta map[string]any `json:\"meta,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, " AdditionalProperties map[string]any `json:\"-\"`")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestFileHeader(t *testing.T) {
+	Convey("Given a schema", t, func() {
+		resetGenerator()
+		opts.Command = "schematyper schema.json"
+		schema := `{"type": "object", "properties": {"name": {"type": "string"}}}`
+
+		Convey("When we generate with a file comment and build tags", func() {
+			opts.FileComment = "//nolint:all\nKeep in sync with schema.json."
+			opts.BuildTags = "generated && !js"
+			src, err := generateFromString(schema)
+
+			Convey("Then the build constraint should come after the banner and the comment after the package clause", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldStartWith, "// Code generated by schematyper schema.json. DO NOT EDIT.\n\n//go:build generated && !js\n\npackage main\n\n//nolint:all\n// Keep in sync with schema.json.\n\n")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate without them", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the file should start with the banner, which marks it as generated", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldStartWith, "// Code generated by schematyper schema.json. DO NOT EDIT.\n\npackage main\n")
+				So(regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`).MatchString(strings.SplitN(src, "\n", 2)[0]), ShouldBeTrue)
+			})
+		})
+
+		Convey("When we generate with a banner", func() {
+			opts.Banner = "Code generated by make schemas. DO NOT EDIT."
+			src, err := generateFromString(schema)
+
+			Convey("Then it should replace the default one", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldStartWith, "// Code generated by make schemas. DO NOT EDIT.\n\npackage main\n")
+			})
+		})
+	})
+}
+
+func TestCommentWidth(t *testing.T) {
+	Convey("Given a schema with long descriptions", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"description": "A user of the service, who signs in with an email address and a password and may belong to teams.",
+			"properties": {
+				"homepage": {
+					"type": "string",
+					"enum": ["personal", "work"],
+					"description": "The kind of homepage, see https://example.com/a/very/long/path/to/the/documentation/of/homepages for details."
+				}
+			}
+		}`
+
+		Convey("When we generate with the default width", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then comments should be wrapped at 80 columns without breaking words", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "// A user of the service, who signs in with an email address and a password and\n// may belong to teams.\ntype schema struct {")
+				So(src, ShouldContainSubstring, "// The kind of homepage, see\n// https://example.com/a/very/long/path/to/the/documentation/of/homepages for\n// details.\ntype homepage string")
+			})
+		})
+
+		Convey("When we generate with a negative width", func() {
+			opts.CommentWidth = -1
+			src, err := generateFromString(schema)
+
+			Convey("Then comments should not be wrapped", func() {
+				So(err, ShouldBeNil)
+				So(src, ShouldContainSubstring, "// A user of the service, who signs in with an email address and a password and may belong to teams.\n")
+			})
+		})
+	})
+
+	Convey("Given an indented comment line", t, func() {
+		Convey("Then it should be kept as it is", func() {
+			line := "    preformatted text that is longer than the width it would otherwise be wrapped at"
+			So(wrapCommentLine(line, 40), ShouldResemble, []string{line})
+		})
+	})
+}
+
+func TestFieldDescriptions(t *testing.T) {
+	Convey("Given a schema with property descriptions", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "description": "The name the user goes by, which doesn't have to be unique across all of the users."},
+				"kind": {"const": "user", "description": "The kind of record."},
+				"address": {"$ref": "#/definitions/address", "description": "Where the user lives."},
+				"age": {"type": "integer"}
+			},
+			"definitions": {
+				"address": {"type": "object", "properties": {"city": {"type": "string"}}}
+			}
+		}`)
+
+		Convey("Then the descriptions should be the fields' comments, wrapped like type comments", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldContainSubstring, "\t// The name the user goes by, which doesn't have to be unique across all of the\n\t// users.\n\tName string")
+			So(src, ShouldContainSubstring, "\t// Where the user lives.\n\tAddress address")
+			So(typeCheck(src), ShouldBeNil)
+		})
+
+		Convey("Then notes about the value should follow the description", func() {
+			So(src, ShouldContainSubstring, "\t// The kind of record.\n\t// must be \"user\"\n\tKind string")
+		})
+
+		Convey("Then fields without a description should have no comment", func() {
+			So(src, ShouldContainSubstring, "\tAddress address `json:\"address,omitempty\"`\n\tAge ")
+		})
+	})
+}
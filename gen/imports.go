@@ -0,0 +1,95 @@
+package gen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/idubinskiy/schematyper/stringset"
+)
+
+// generatedImports are the packages that generated code can use, by name.
+var generatedImports = map[string]string{
+	"bytes":  "bytes",
+	"driver": "database/sql/driver",
+	"errors": "errors",
+	"fmt":    "fmt",
+	"io":     "io",
+	"json":   "encoding/json",
+	"time":   "time",
+}
+
+// usedImports returns the paths of the generatedImports, and of the packages of the format types, that the
+// declarations in body refer to, so that a file imports exactly the packages it uses, whichever types and methods
+// are printed to it.
+func (g *generator) usedImports(body []byte) ([]string, error) {
+	src := append([]byte("package p\n\n"), body...)
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	importPaths := make(map[string]string, len(generatedImports)+len(g.importNames))
+	for name, importPath := range generatedImports {
+		importPaths[name] = importPath
+	}
+	for importPath, name := range g.importNames {
+		importPaths[name] = importPath
+	}
+	var imports []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			// a package name isn't declared in the file, so it isn't resolved
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				if importPath, ok := importPaths[ident.Name]; ok {
+					imports = append(imports, importPath)
+				}
+			}
+		}
+		return true
+	})
+	return imports, nil
+}
+
+// importSpec returns the import declaration for importPath, which names the package if its name can't be inferred
+// from the path.
+func (g *generator) importSpec(importPath string) string {
+	if name, ok := g.importNames[importPath]; ok && name != path.Base(importPath) {
+		return name + " " + strconv.Quote(importPath)
+	}
+	return strconv.Quote(importPath)
+}
+
+// writeImports writes the import declaration for imports, which may have duplicates, to buf. Standard library
+// packages are grouped before other packages, and each group is sorted.
+func (g *generator) writeImports(buf *bytes.Buffer, imports []string) {
+	var std, other []string
+	for _, imp := range stringset.New(imports...).Sorted() {
+		// standard library paths don't start with a domain name
+		if strings.Contains(strings.SplitN(imp, "/", 2)[0], ".") {
+			other = append(other, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	switch {
+	case len(std)+len(other) == 0:
+	case len(std)+len(other) == 1:
+		buf.WriteString("import " + g.importSpec(append(std, other...)[0]) + "\n")
+	default:
+		buf.WriteString("import (\n")
+		for _, imp := range std {
+			buf.WriteString(g.importSpec(imp) + "\n")
+		}
+		if len(std) > 0 && len(other) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, imp := range other {
+			buf.WriteString(g.importSpec(imp) + "\n")
+		}
+		buf.WriteString(")\n")
+	}
+}
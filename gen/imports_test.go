@@ -0,0 +1,40 @@
+package gen
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUsedImports(t *testing.T) {
+	Convey("Given a schema whose types need several packages", t, func() {
+		resetGenerator()
+		opts.UUIDType = "github.com/gofrs/uuid/v5.UUID"
+		opts.EnumMarshalCheck = true
+		opts.NDJSONDecoder = true
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "format": "uuid"},
+				"createdAt": {"type": "string", "format": "date-time"},
+				"status": {"type": "string", "enum": ["active", "closed"]}
+			}
+		}`)
+
+		Convey("Then each should be imported once, with the standard library grouped first", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldContainSubstring, "import (\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"io\"\n\t\"time\"\n\n\tuuid \"github.com/gofrs/uuid/v5\"\n)\n")
+		})
+	})
+
+	Convey("Given a schema whose types need no packages", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{"type": "object", "properties": {"name": {"type": "string"}}}`)
+
+		Convey("Then nothing should be imported", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldNotContainSubstring, "import")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,125 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/idubinskiy/schematyper/stringset"
+)
+
+// unsupportedKeywords are the JSON Schema keywords that would change the generated types but that the generator
+// ignores. Keywords that only constrain values, such as minLength, don't change the types, so they aren't listed.
+var unsupportedKeywords = stringset.New(
+	"$dynamicRef",
+	"$recursiveRef",
+	"additionalItems",
+	"anyOf",
+	"contains",
+	"dependencies",
+	"dependentSchemas",
+	"else",
+	"if",
+	"not",
+	"propertyNames",
+	"then",
+	"unevaluatedItems",
+	"unevaluatedProperties",
+)
+
+// keywordUse is a keyword in a schema document and the JSON pointer of the schema that has it.
+type keywordUse struct {
+	keyword string
+	path    string
+	// nested is true if the keyword's value is an object or array, such as the schemas of anyOf, which are ignored
+	// along with it, whatever keywords they have.
+	nested bool
+}
+
+func (u keywordUse) String() string {
+	if u.nested {
+		return u.keyword + " at " + u.path + " and everything in it"
+	}
+	return u.keyword + " at " + u.path
+}
+
+// propertyDependencies reports whether v, the value of a dependencies keyword, only lists required properties, which
+// are noted in the type's comment. Schema dependencies are still unsupported.
+func propertyDependencies(v interface{}) bool {
+	deps, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, dep := range deps {
+		if _, ok := dep.([]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// findUnsupportedKeywords returns the uses of unsupported keywords in v, a decoded schema document of the given kind
+// at path, sorted by path. The schemas under an unsupported keyword are ignored along with it, even the keywords in
+// them that are supported elsewhere, so they aren't searched, and the use says that they are ignored.
+func findUnsupportedKeywords(v interface{}, kind schemaKind, path string) []keywordUse {
+	var uses []keywordUse
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if kind == notSchema {
+			break
+		}
+		keys, _ := stringset.FromMapKeys(v)
+		var children []string
+		for _, key := range keys.Sorted() {
+			if kind != schemaMap && unsupportedKeywords.Has(key) && !(key == "dependencies" && propertyDependencies(v[key])) {
+				_, isObject := v[key].(map[string]interface{})
+				_, isArray := v[key].([]interface{})
+				uses = append(uses, keywordUse{keyword: key, path: path, nested: isObject || isArray})
+			} else {
+				children = append(children, key)
+			}
+		}
+		// the schema's own keywords come before those of the schemas it contains
+		for _, key := range children {
+			childKind := notSchema
+			switch kind {
+			case schemaValue, schemaOrArray:
+				childKind = schemaKeywordKinds[key]
+			case schemaMap:
+				childKind = schemaValue
+			}
+			uses = append(uses, findUnsupportedKeywords(v[key], childKind, path+"/"+pointerToken(key))...)
+		}
+	case []interface{}:
+		if kind != schemaArray && kind != schemaOrArray {
+			break
+		}
+		for i, val := range v {
+			uses = append(uses, findUnsupportedKeywords(val, schemaValue, fmt.Sprintf("%s/%d", path, i))...)
+		}
+	}
+	return uses
+}
+
+// checkKeywords logs a warning for each unsupported keyword in the schema document in schemaJSON, or returns an error
+// listing them if StrictKeywords is set. Documents referred to by $ref aren't checked.
+func (g *generator) checkKeywords(schemaJSON []byte) error {
+	raw, err := decodeSchemaJSON(schemaJSON)
+	if err != nil {
+		return fmt.Errorf("parsing JSON: %s", err)
+	}
+	uses := findUnsupportedKeywords(raw, schemaValue, "#")
+	if len(uses) == 0 {
+		return nil
+	}
+	if g.StrictKeywords {
+		listed := make([]string, len(uses))
+		for i, use := range uses {
+			listed[i] = use.String()
+		}
+		return fmt.Errorf("unsupported keywords: %s", strings.Join(listed, ", "))
+	}
+	for _, use := range uses {
+		g.warnf("Ignoring unsupported keyword %s\n", use)
+	}
+	return nil
+}
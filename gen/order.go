@@ -0,0 +1,139 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// propertyOrderKey is the keyword that records the order of a schema's properties, which is lost when they are
+// unmarshalled into a map.
+const propertyOrderKey = "x-go-property-order"
+
+// jsonObject is a decoded JSON object that remembers the order of its keys.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// schemaKind is what a JSON value in a schema document holds, which determines where property order is recorded.
+type schemaKind int
+
+const (
+	notSchema schemaKind = iota
+	schemaValue
+	schemaMap
+	schemaArray
+	// schemaOrArray is a schema or an array of schemas, like items.
+	schemaOrArray
+)
+
+// schemaKeywordKinds are the kinds of the values of the schema keywords that hold other schemas.
+var schemaKeywordKinds = map[string]schemaKind{
+	"properties":           schemaMap,
+	"patternProperties":    schemaMap,
+	"definitions":          schemaMap,
+	"$defs":                schemaMap,
+	"items":                schemaOrArray,
+	"prefixItems":          schemaArray,
+	"additionalItems":      schemaValue,
+	"additionalProperties": schemaValue,
+	"not":                  schemaValue,
+	"allOf":                schemaArray,
+	"anyOf":                schemaArray,
+	"oneOf":                schemaArray,
+}
+
+// decodeSchemaJSON decodes the schema document in data like json.Unmarshal into an interface{}, except that the
+// properties of each schema are listed in the order they appear under propertyOrderKey.
+func decodeSchemaJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return withPropertyOrder(v, schemaValue), nil
+}
+
+// decodeOrdered decodes the next JSON value from dec, with objects as jsonObjects.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := jsonObject{values: make(map[string]interface{})}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := obj.values[key]; !ok {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.values[key] = val
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// withPropertyOrder converts the jsonObjects in v, which is of the given kind, to maps, adding propertyOrderKey to
+// the schemas that have properties.
+func withPropertyOrder(v interface{}, kind schemaKind) interface{} {
+	switch v := v.(type) {
+	case jsonObject:
+		m := make(map[string]interface{}, len(v.values)+1)
+		for key, val := range v.values {
+			childKind := notSchema
+			switch kind {
+			case schemaValue, schemaOrArray:
+				childKind = schemaKeywordKinds[key]
+			case schemaMap:
+				childKind = schemaValue
+			}
+			m[key] = withPropertyOrder(val, childKind)
+		}
+		if props, ok := v.values["properties"].(jsonObject); ok && (kind == schemaValue || kind == schemaOrArray) {
+			order := make([]interface{}, len(props.keys))
+			for i, key := range props.keys {
+				order[i] = key
+			}
+			m[propertyOrderKey] = order
+		}
+		return m
+	case []interface{}:
+		childKind := notSchema
+		if kind == schemaArray || kind == schemaOrArray {
+			childKind = schemaValue
+		}
+		for i, val := range v {
+			v[i] = withPropertyOrder(val, childKind)
+		}
+		return v
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Values of Options.MapType.
+const (
+	MapTypeMap     = "map"
+	MapTypeOrdered = "ordered"
+)
+
+// orderedMapSrc is the source of the generic ordered map type used instead of maps under --map-type=ordered. The
+// type's name is substituted for %[1]s.
+const orderedMapSrc = `// %[1]s is a map from strings to values of type V that keeps its keys in insertion order, including when marshalled
+// to and unmarshalled from JSON. Unlike a map, its zero value is ready to use.
+type %[1]s[V any] struct {
+	keys   []string
+	values map[string]V
+}
+
+// Get returns the value for key and whether key is present.
+func (m *%[1]s[V]) Get(key string) (V, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set sets the value for key, adding key after all existing keys if it isn't already present.
+func (m *%[1]s[V]) Set(key string, v V) {
+	if m.values == nil {
+		m.values = make(map[string]V)
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// Delete removes key and its value.
+func (m *%[1]s[V]) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in insertion order.
+func (m *%[1]s[V]) Keys() []string {
+	return m.keys
+}
+
+// Len returns the number of keys.
+func (m *%[1]s[V]) Len() int {
+	return len(m.keys)
+}
+
+// MarshalJSON encodes the map as a JSON object with its keys in insertion order.
+func (m %[1]s[V]) MarshalJSON() ([]byte, error) {
+	if m.values == nil {
+		return []byte("null"), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valJSON, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(valJSON)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object, keeping its keys in the order they appear.
+func (m *%[1]s[V]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		*m = %[1]s[V]{}
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return errors.New("%[1]s: expected a JSON object")
+	}
+
+	*m = %[1]s[V]{values: make(map[string]V)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var v V
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		m.Set(tok.(string), v)
+	}
+	_, err = dec.Token()
+	return err
+}
+`
+
+func (g *generator) orderedMapName() string {
+	return g.generateIdentifier("ordered-map", g.exportTypes())
+}
+
+// mapTypeString replaces each map[string] in the Go type typeStr with the ordered map type when --map-type=ordered.
+// A map's value type always extends to the end of typeStr, so the closing brackets are all appended at the end.
+func (g *generator) mapTypeString(typeStr string) string {
+	if g.MapType != MapTypeOrdered {
+		return typeStr
+	}
+	count := strings.Count(typeStr, "map[string]")
+	if count == 0 {
+		return typeStr
+	}
+	return strings.Replace(typeStr, "map[string]", g.orderedMapName()+"[", -1) + strings.Repeat("]", count)
+}
+
+func (g *generator) printOrderedMap(buf *bytes.Buffer) {
+	buf.WriteString(fmt.Sprintf(orderedMapSrc, g.orderedMapName()))
+}
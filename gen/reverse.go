@@ -0,0 +1,214 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// reverser builds a JSON schema from Go types. Named types from the package become definitions.
+type reverser struct {
+	pkg      *gotypes.Package
+	root     *gotypes.TypeName
+	docs     map[string]string
+	defs     map[string]metaSchema
+	resolved map[string]bool
+}
+
+// reverseSchema returns a JSON schema for the type named typeName in the Go package in dir. Structs, slices, maps,
+// and scalars are supported; other types have an empty schema, which allows any value.
+func reverseSchema(dir, typeName string) (*metaSchema, error) {
+	fset := token.NewFileSet()
+	notTest := func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, dir, notTest, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, astPkg := range pkgs {
+		var files []*ast.File
+		docs := make(map[string]string)
+		for _, file := range astPkg.Files {
+			files = append(files, file)
+			collectTypeDocs(file, docs)
+		}
+
+		conf := gotypes.Config{Importer: importer.Default()}
+		pkg, err := conf.Check(astPkg.Name, fset, files, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		root, ok := pkg.Scope().Lookup(typeName).(*gotypes.TypeName)
+		if !ok {
+			continue
+		}
+
+		r := reverser{
+			pkg:      pkg,
+			root:     root,
+			docs:     docs,
+			defs:     make(map[string]metaSchema),
+			resolved: make(map[string]bool),
+		}
+		s := r.schemaFor(root.Type().Underlying())
+		s.Description = docs[typeName]
+		if len(r.defs) > 0 {
+			s.Definitions = r.defs
+		}
+		return &s, nil
+	}
+
+	return nil, fmt.Errorf("type %s not found in %s", typeName, dir)
+}
+
+// collectTypeDocs adds the doc comments of the type declarations in file to docs.
+func collectTypeDocs(file *ast.File, docs map[string]string) {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+			if doc != nil {
+				docs[typeSpec.Name.Name] = strings.TrimSpace(doc.Text())
+			}
+		}
+	}
+}
+
+func (r *reverser) schemaFor(t gotypes.Type) metaSchema {
+	switch t := t.(type) {
+	case *gotypes.Named:
+		obj := t.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return metaSchema{Type: typeString, Format: "date-time"}
+		}
+		if obj.Pkg() != nil && obj.Pkg().Path() == "encoding/json" && obj.Name() == "RawMessage" {
+			// any JSON value, although it is a []byte
+			return metaSchema{}
+		}
+		if obj == r.root {
+			return metaSchema{Ref: "#"}
+		}
+		if obj.Pkg() != r.pkg {
+			return r.schemaFor(t.Underlying())
+		}
+
+		name := obj.Name()
+		if !r.resolved[name] {
+			// mark before recursing so that recursive types refer to the definition
+			r.resolved[name] = true
+			def := r.schemaFor(t.Underlying())
+			def.Description = r.docs[name]
+			r.defs[name] = def
+		}
+		return metaSchema{Ref: "#/definitions/" + name}
+	case *gotypes.Basic:
+		switch {
+		case t.Info()&gotypes.IsBoolean != 0:
+			return metaSchema{Type: typeBoolean}
+		case t.Info()&gotypes.IsInteger != 0:
+			return metaSchema{Type: typeInteger}
+		case t.Info()&gotypes.IsFloat != 0:
+			return metaSchema{Type: typeNumber}
+		case t.Info()&gotypes.IsString != 0:
+			return metaSchema{Type: typeString}
+		}
+	case *gotypes.Pointer:
+		s := r.schemaFor(t.Elem())
+		if jsonType, ok := s.Type.(string); ok {
+			s.Type = []interface{}{jsonType, typeNull}
+		}
+		return s
+	case *gotypes.Slice:
+		if elem, ok := t.Elem().Underlying().(*gotypes.Basic); ok && elem.Kind() == gotypes.Uint8 {
+			// encoding/json marshals a []byte as a base64 string
+			return metaSchema{Type: typeString, ContentEncoding: "base64"}
+		}
+		if _, ok := t.Elem().(*gotypes.Interface); ok {
+			return metaSchema{Type: typeArray}
+		}
+		return metaSchema{Type: typeArray, Items: r.schemaFor(t.Elem())}
+	case *gotypes.Map:
+		if _, ok := t.Elem().(*gotypes.Interface); ok {
+			return metaSchema{Type: typeObject}
+		}
+		return metaSchema{Type: typeObject, AdditionalProperties: r.schemaFor(t.Elem())}
+	case *gotypes.Struct:
+		return r.structSchema(t)
+	}
+	return metaSchema{}
+}
+
+// structSchema returns an object schema with a property for each field that encoding/json would marshal. Embedded
+// structs without a name in their json tag are composed with allOf. A number or boolean with the tag's string option
+// gets x-go-json-string, as its values are JSON strings.
+func (r *reverser) structSchema(t *gotypes.Struct) metaSchema {
+	s := metaSchema{Type: typeObject}
+	props := make(map[string]metaSchema)
+	for i := 0; i < t.NumFields(); i++ {
+		field := t.Field(i)
+		tag := reflect.StructTag(t.Tag(i)).Get("json")
+		if tag == "-" {
+			continue
+		}
+		tagParts := strings.Split(tag, ",")
+		propName := tagParts[0]
+
+		if field.Anonymous() && propName == "" {
+			s.AllOf = append(s.AllOf, r.schemaFor(field.Type()))
+			continue
+		}
+		if !field.Exported() {
+			continue
+		}
+		if propName == "" {
+			propName = field.Name()
+		}
+
+		prop := r.schemaFor(field.Type())
+		omitEmpty := false
+		for _, opt := range tagParts[1:] {
+			switch opt {
+			case "omitempty":
+				omitEmpty = true
+			case "string":
+				prop.GoJSONString = quotedScalar(field.Type())
+			}
+		}
+		props[propName] = prop
+		if !omitEmpty {
+			s.Required = append(s.Required, metaStringArrayItem(propName))
+		}
+	}
+	if len(props) > 0 {
+		s.Properties = props
+	}
+	if len(s.AllOf) > 0 {
+		// without a type, the object type is inferred from the allOf schemas, which are then embedded
+		s.Type = nil
+	}
+	return s
+}
+
+// quotedScalar returns true if t, or the type t points to, is a number or boolean, which are the only types whose
+// values encoding/json quotes for the tag's string option.
+func quotedScalar(t gotypes.Type) bool {
+	if ptr, ok := t.Underlying().(*gotypes.Pointer); ok {
+		t = ptr.Elem()
+	}
+	basic, ok := t.Underlying().(*gotypes.Basic)
+	return ok && basic.Info()&(gotypes.IsNumeric|gotypes.IsBoolean) != 0
+}
@@ -0,0 +1,135 @@
+package gen
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReverseSchema(t *testing.T) {
+	Convey("Given Go types generated from a schema", t, func() {
+		resetGenerator()
+		schema := `{
+			"description": "A person.",
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"age": {"type": ["integer", "null"]},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"scores": {"type": "object", "additionalProperties": {"type": "number"}},
+				"address": {"$ref": "#/definitions/address"},
+				"born": {"type": "string", "format": "date-time"},
+				"extra": {}
+			},
+			"required": ["name"],
+			"definitions": {
+				"address": {
+					"description": "A postal address.",
+					"type": "object",
+					"properties": {"city": {"type": "string"}}
+				}
+			}
+		}`
+		src, err := generateFromString(schema)
+		So(err, ShouldBeNil)
+
+		dir, err := ioutil.TempDir("", "schematyper")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(ioutil.WriteFile(filepath.Join(dir, "schematype.go"), []byte(src), 0644), ShouldBeNil)
+
+		Convey("When we build a schema from the root type", func() {
+			reversed, err := reverseSchema(dir, "schema")
+			So(err, ShouldBeNil)
+
+			Convey("Then it should describe the same structure", func() {
+				So(reversed.Description, ShouldEqual, "A person.")
+				So(reversed.Required, ShouldResemble, metaStringArray{"name"})
+				So(reversed.Properties["age"].Type, ShouldResemble, []interface{}{"integer", "null"})
+				So(reversed.Properties["born"].Format, ShouldEqual, "date-time")
+				So(reversed.Properties["address"].Ref, ShouldEqual, "#/definitions/address")
+				So(reversed.Definitions["address"].Description, ShouldEqual, "A postal address.")
+			})
+
+			Convey("Then generating from it should produce the same Go types", func() {
+				reversedJSON, err := json.Marshal(reversed)
+				So(err, ShouldBeNil)
+
+				resetGenerator()
+				roundTripSrc, err := generateFromString(string(reversedJSON))
+				So(err, ShouldBeNil)
+				So(roundTripSrc, ShouldEqual, src)
+			})
+		})
+
+		Convey("When we build a schema for a type that doesn't exist", func() {
+			_, err := reverseSchema(dir, "missing")
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given Go types with byte slices and the json tag's string option", t, func() {
+		src := `package files
+
+import "encoding/json"
+
+type file struct {
+	Name    string          ` + "`json:\"name\"`" + `
+	Data    []byte          ` + "`json:\"data\"`" + `
+	Size    int64           ` + "`json:\"size,string\"`" + `
+	Shared  *bool           ` + "`json:\"shared,omitempty,string\"`" + `
+	Label   string          ` + "`json:\"label,string\"`" + `
+	Payload json.RawMessage ` + "`json:\"payload\"`" + `
+}
+`
+		dir, err := ioutil.TempDir("", "schematyper")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(ioutil.WriteFile(filepath.Join(dir, "files.go"), []byte(src), 0644), ShouldBeNil)
+
+		Convey("When we build a schema from the type", func() {
+			reversed, err := reverseSchema(dir, "file")
+			So(err, ShouldBeNil)
+
+			Convey("Then the byte slice should be a base64 string", func() {
+				So(reversed.Properties["data"].Type, ShouldEqual, "string")
+				So(reversed.Properties["data"].ContentEncoding, ShouldEqual, "base64")
+			})
+
+			Convey("Then numbers and booleans with the string option should be marked as JSON strings", func() {
+				So(reversed.Properties["size"].Type, ShouldEqual, "integer")
+				So(reversed.Properties["size"].GoJSONString, ShouldBeTrue)
+				So(reversed.Properties["shared"].Type, ShouldResemble, []interface{}{"boolean", "null"})
+				So(reversed.Properties["shared"].GoJSONString, ShouldBeTrue)
+				So(reversed.Required, ShouldResemble, metaStringArray{"name", "data", "size", "label", "payload"})
+			})
+
+			Convey("Then other fields should ignore the string option", func() {
+				So(reversed.Properties["label"].GoJSONString, ShouldBeFalse)
+				So(reversed.Properties["name"].ContentEncoding, ShouldBeEmpty)
+			})
+
+			Convey("Then json.RawMessage should allow any value", func() {
+				So(reversed.Properties["payload"], ShouldResemble, metaSchema{})
+			})
+
+			Convey("Then generating from it should give the string option back", func() {
+				reversedJSON, err := json.Marshal(reversed)
+				So(err, ShouldBeNil)
+
+				resetGenerator()
+				genSrc, err := generateFromString(string(reversedJSON))
+				So(err, ShouldBeNil)
+				So(compact(genSrc), ShouldContainSubstring, "Size int `json:\"size,string\"`")
+				So(compact(genSrc), ShouldContainSubstring, "Shared *bool `json:\"shared,omitempty,string\"`")
+			})
+		})
+	})
+}
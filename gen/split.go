@@ -0,0 +1,144 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/idubinskiy/schematyper/stringset"
+)
+
+// renderFiles prints each processed type, along with its methods, to its own formatted file named after it, and each
+// helper shared by the types, such as the Ptr function, to a file named after the helper. The NDJSON decoder goes in
+// the root type's file. Each file imports only the packages it uses. The files of DefinitionsPackage are in a
+// directory named after it, along with their own copies of the helpers they use.
+func (g *generator) renderFiles() (map[string][]byte, error) {
+	bodies := make(map[string]*bytes.Buffer)
+	filePkgs := make(map[string]string)
+	var fileNames []string
+	newFile := func(name string) *bytes.Buffer {
+		fileName := typeFileName(name)
+		if g.printPkg != "" {
+			fileName = path.Join(importPathName(g.printPkg), fileName)
+		}
+		if _, ok := bodies[fileName]; ok {
+			g.fail("can't write %s to %s, which is already used by another type", name, fileName)
+		}
+		bodies[fileName] = &bytes.Buffer{}
+		filePkgs[fileName] = g.printPkg
+		fileNames = append(fileNames, fileName)
+		return bodies[fileName]
+	}
+
+	rootPath := "#"
+	if ref, ok := g.transitiveRefs[rootPath]; ok {
+		rootPath = ref
+	}
+	pkgs := []string{""}
+	if g.DefinitionsPackage != "" {
+		pkgs = append(pkgs, g.DefinitionsPackage)
+	}
+	for _, pkg := range pkgs {
+		g.printPkg = pkg
+		usesOrderedMap, usesPasswordType := false, false
+		civilTypes := stringset.New()
+		for _, gt := range g.sortedTypes() {
+			if gt.pkg != pkg {
+				continue
+			}
+			buf := newFile(gt.Name)
+			g.printTypeDecls(buf, gt)
+			if g.NDJSONDecoder && gt.Name == g.types[rootPath].Name {
+				g.printNDJSONDecoder(buf)
+			}
+			usesOrderedMap = usesOrderedMap || g.usesOrderedMap(buf.String())
+			usesPasswordType = usesPasswordType || g.usesPasswordType(gt)
+			for _, format := range g.civilTypes.Sorted() {
+				if usesNamedType(gt, g.civilTypeName(format)) {
+					civilTypes.Add(format)
+				}
+			}
+		}
+		if usesPasswordType {
+			g.printPasswordType(newFile(g.passwordTypeName()))
+		}
+		for _, format := range civilTypes.Sorted() {
+			g.printCivilType(newFile(g.civilTypeName(format)), format)
+		}
+		if g.EmitPtrHelpers && pkg == "" {
+			g.printPtrHelper(newFile(g.ptrHelperName()))
+		}
+		if usesOrderedMap {
+			g.printOrderedMap(newFile(g.orderedMapName()))
+		}
+	}
+
+	files := make(map[string][]byte, len(bodies))
+	for _, fileName := range fileNames {
+		g.printPkg = filePkgs[fileName]
+		body := bodies[fileName].Bytes()
+		imports, err := g.usedImports(body)
+		if err != nil && !g.NoFormat {
+			return nil, fmt.Errorf("%s: %s", fileName, err)
+		}
+		if files[fileName], err = g.formatFile(body, imports); err != nil {
+			return nil, fmt.Errorf("%s: %s", fileName, err)
+		}
+	}
+	return files, nil
+}
+
+// usesPasswordType returns true if gt, or one of its fields, is of the password type used under --redact-passwords.
+func (g *generator) usesPasswordType(gt goType) bool {
+	if !g.needPasswordType {
+		return false
+	}
+	return usesNamedType(gt, g.passwordTypeName())
+}
+
+// usesNamedType returns true if gt, or one of its fields, is of the generated type name, or a slice, map or pointer of
+// it.
+func usesNamedType(gt goType, name string) bool {
+	if strings.HasSuffix(gt.TypePrefix, name) {
+		return true
+	}
+	for _, sf := range gt.Fields {
+		if strings.HasSuffix(sf.TypePrefix, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildConstraintSuffixes are the file name suffixes that the go command treats as build constraints.
+var buildConstraintSuffixes = strings.Fields(`test
+	aix android darwin dragonfly freebsd hurd illumos ios js linux nacl netbsd openbsd plan9 solaris wasip1 windows zos
+	386 amd64 amd64p32 arm armbe arm64 arm64be loong64 mips mipsle mips64 mips64le mips64p32 mips64p32le ppc ppc64
+	ppc64le riscv riscv64 s390 s390x sparc sparc64 wasm`)
+
+// typeFileName returns the name of the file for the type or helper name in snake case, e.g. user_id.go for UserID.
+// Names that the go command would treat as test files or build constraints, such as load_test.go, get a _type suffix.
+func typeFileName(name string) string {
+	var snake []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				snake = append(snake, '_')
+			}
+		}
+		snake = append(snake, unicode.ToLower(r))
+	}
+	base := strings.TrimLeft(string(snake), "_")
+	for _, suffix := range buildConstraintSuffixes {
+		if strings.HasSuffix(base, "_"+suffix) {
+			base += "_type"
+			break
+		}
+	}
+	return base + ".go"
+}
@@ -0,0 +1,171 @@
+package gen
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTypeFileName(t *testing.T) {
+	Convey("Given type and helper names", t, func() {
+		cases := map[string]string{
+			"userID":       "user_id.go",
+			"UserID":       "user_id.go",
+			"HTTPServer":   "http_server.go",
+			"address2Line": "address2_line.go",
+			"schema":       "schema.go",
+			"loadTest":     "load_test_type.go",
+			"buildLinux":   "build_linux_type.go",
+			"Ptr":          "ptr.go",
+		}
+
+		Convey("Then the file names should be in snake case and not look like build constraints", func() {
+			for name, fileName := range cases {
+				So(typeFileName(name), ShouldEqual, fileName)
+			}
+		})
+	})
+}
+
+func TestGenerateFiles(t *testing.T) {
+	Convey("Given a schema with several types", t, func() {
+		schema := []byte(`{
+			"type": "object",
+			"properties": {
+				"createdAt": {"type": "string", "format": "date-time"},
+				"dueOn": {"type": "string", "format": "date"},
+				"homeAddress": {"type": "object", "properties": {"street": {"type": "string"}}},
+				"status": {"type": "string", "enum": ["active", "closed"]},
+				"score": {"type": ["integer", "null"]}
+			}
+		}`)
+
+		Convey("When we generate files", func() {
+			files, err := GenerateFiles(schema, Options{PackageName: "orders", SchemaName: "order", EnumMarshalCheck: true, EmitPtrHelpers: true})
+
+			Convey("Then there should be a file for each type and helper", func() {
+				So(err, ShouldBeNil)
+				var fileNames []string
+				for fileName := range files {
+					fileNames = append(fileNames, fileName)
+				}
+				sort.Strings(fileNames)
+				So(fileNames, ShouldResemble, []string{"date.go", "home_address.go", "order.go", "ptr.go", "status.go"})
+			})
+
+			Convey("Then each file should have the header and only the imports it uses", func() {
+				for _, src := range files {
+					So(string(src), ShouldStartWith, "// Code generated by schematyper. DO NOT EDIT.\n\npackage orders\n")
+				}
+				So(string(files["order.go"]), ShouldContainSubstring, "import \"time\"\n")
+				So(string(files["status.go"]), ShouldContainSubstring, "import (\n\t\"encoding/json\"\n\t\"fmt\"\n)\n")
+				So(string(files["date.go"]), ShouldContainSubstring, "import \"time\"\n")
+				So(string(files["home_address.go"]), ShouldNotContainSubstring, "import")
+				So(string(files["ptr.go"]), ShouldNotContainSubstring, "import")
+			})
+
+			Convey("Then the files should compile together", func() {
+				var srcs []string
+				for _, src := range files {
+					srcs = append(srcs, string(src))
+				}
+				So(typeCheck(srcs...), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate files and a single file", func() {
+			opts := Options{SchemaName: "order"}
+			files, err := GenerateFiles(schema, opts)
+			So(err, ShouldBeNil)
+			src, err := Generate(schema, opts)
+			So(err, ShouldBeNil)
+
+			Convey("Then the files should declare the same types", func() {
+				for _, fileSrc := range files {
+					So(compact(string(src)), ShouldContainSubstring, compact(string(fileSrc)[strings.Index(string(fileSrc), "\ntype "):]))
+				}
+			})
+		})
+	})
+}
+
+func TestDefinitionsPackage(t *testing.T) {
+	Convey("Given a schema whose definitions go in another package", t, func() {
+		opts := Options{SchemaName: "order", DefinitionsPackage: "example.com/shop/types"}
+		schema := []byte(`{
+			"type": "object",
+			"properties": {
+				"shipping": {"$ref": "#/definitions/address"},
+				"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}},
+				"note": {"type": "object", "properties": {"text": {"type": "string"}}}
+			},
+			"definitions": {
+				"address": {"type": "object", "properties": {"geo": {"type": "object", "properties": {"lat": {"type": "number"}}}}}
+			},
+			"$defs": {
+				"tag": {"type": "string"}
+			}
+		}`)
+
+		Convey("When we generate files", func() {
+			files, err := GenerateFiles(schema, opts)
+
+			Convey("Then the definitions and the types nested in them should be in the package's directory", func() {
+				So(err, ShouldBeNil)
+				var fileNames []string
+				for fileName := range files {
+					fileNames = append(fileNames, fileName)
+				}
+				sort.Strings(fileNames)
+				So(fileNames, ShouldResemble, []string{"note.go", "order.go", "types/address.go", "types/geo.go", "types/tag.go"})
+				var srcs []string
+				for _, fileName := range fileNames[2:] {
+					So(string(files[fileName]), ShouldContainSubstring, "\npackage types\n")
+					srcs = append(srcs, string(files[fileName]))
+				}
+				So(typeCheck(srcs...), ShouldBeNil)
+			})
+
+			Convey("Then the other types should refer to them by the package's name", func() {
+				order := compact(string(files["order.go"]))
+				So(order, ShouldContainSubstring, "\npackage main\n")
+				So(order, ShouldContainSubstring, "import \"example.com/shop/types\"")
+				So(order, ShouldContainSubstring, "Shipping types.Address `json:\"shipping,omitempty\"`")
+				So(order, ShouldContainSubstring, "Tags []types.Tag `json:\"tags,omitempty\"`")
+				So(order, ShouldContainSubstring, "Note Note `json:\"note,omitempty\"`")
+				So(string(files["note.go"]), ShouldNotContainSubstring, "import")
+			})
+		})
+
+		Convey("When we generate a single file", func() {
+			_, err := Generate(schema, opts)
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given a definition that refers to the root type", t, func() {
+		schema := []byte(`{
+			"type": "object",
+			"properties": {
+				"child": {"$ref": "#/definitions/child"}
+			},
+			"definitions": {
+				"child": {"type": "object", "properties": {"parent": {"$ref": "#"}}}
+			}
+		}`)
+
+		Convey("When we generate files with a definitions package", func() {
+			_, err := GenerateFiles(schema, Options{DefinitionsPackage: "example.com/shop/types"})
+
+			Convey("Then there should be an error, since the packages would import each other", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "import each other")
+			})
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package gen
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// writeSummary writes an overview of the generated types in src to w: their names, which were renamed to
+// disambiguate them, which fields can hold any value because their type couldn't be determined, and the imports.
+func (g *generator) writeSummary(w io.Writer, src []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return err
+	}
+	var imports []string
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		imports = append(imports, path)
+	}
+
+	var names, renamed, fallbacks []string
+	for path, gt := range g.types {
+		names = append(names, gt.Name)
+		if origName, ok := g.renamedTypes[path]; ok {
+			renamed = append(renamed, fmt.Sprintf("%s (was %s)", gt.Name, origName))
+		}
+		for _, sf := range gt.Fields {
+			if !sf.Embedded && strings.Contains(g.typeString(sf), typeEmptyInterface) {
+				fallbacks = append(fallbacks, gt.Name+"."+sf.Name)
+			}
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "types (%d): %s\nrenamed: %s\ninterface{} fields: %s\nimports: %s\n",
+		len(names), summaryList(names), summaryList(renamed), summaryList(fallbacks), summaryList(imports))
+	return err
+}
+
+// writeStats writes the counts of the generated types, the deferred types that were resolved, and the name collisions,
+// which are the types renamed to disambiguate them, to w.
+func (g *generator) writeStats(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "types: %d\ndeferred types resolved: %d\nname collisions: %d\n",
+		len(g.types), g.deferredResolved, len(g.renamedTypes))
+	return err
+}
+
+func summaryList(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
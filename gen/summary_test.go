@@ -0,0 +1,72 @@
+package gen
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteSummary(t *testing.T) {
+	Convey("Given a schema with ambiguous names and untyped properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"home": {
+					"type": "object",
+					"properties": {"address": {"type": "object", "properties": {"street": {"type": "string"}}}}
+				},
+				"work": {
+					"type": "object",
+					"properties": {"address": {"type": "object", "properties": {"suite": {}}}}
+				},
+				"extra": {},
+				"tags": {"type": "array"},
+				"updated": {"type": "string", "format": "date-time"}
+			}
+		}`
+
+		Convey("When we generate with a summary writer", func() {
+			var buf bytes.Buffer
+			opts.Summary = &buf
+			_, err := generateFromString(schema)
+
+			Convey("Then it should list the types, renames, interface{} fields, and imports", func() {
+				So(err, ShouldBeNil)
+				So(buf.String(), ShouldEqual, "types (5): home, homeAddress, schema, work, workAddress\n"+
+					"renamed: homeAddress (was address), workAddress (was address)\n"+
+					"interface{} fields: schema.Extra, schema.Tags, workAddress.Suite\n"+
+					"imports: time\n")
+			})
+		})
+	})
+}
+
+func TestWriteStats(t *testing.T) {
+	Convey("Given a schema with a forward reference and ambiguous names", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"home": {"type": "object", "properties": {"address": {"type": "object", "properties": {"street": {"type": "string"}}}}},
+				"work": {"type": "object", "properties": {"address": {"type": "object", "properties": {"suite": {"type": "string"}}}}}
+			},
+			"definitions": {
+				"location": {"type": "object", "properties": {"geo": {"$ref": "#/definitions/point"}}},
+				"point": {"type": "object", "properties": {"lat": {"type": "number"}}}
+			}
+		}`
+
+		Convey("When we generate with a stats writer", func() {
+			var buf bytes.Buffer
+			opts.Stats = &buf
+			_, err := generateFromString(schema)
+
+			Convey("Then it should count the types, resolved deferred types, and name collisions", func() {
+				So(err, ShouldBeNil)
+				So(buf.String(), ShouldEqual, "types: 7\ndeferred types resolved: 1\nname collisions: 2\n")
+			})
+		})
+	})
+}
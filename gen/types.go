@@ -0,0 +1,171 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Values of Options.NumberType.
+const (
+	NumberTypeFloat64    = "float64"
+	NumberTypeJSONNumber = "json.Number"
+)
+
+// majorVersionElem matches the last element of an import path that is a major version, as in
+// github.com/gofrs/uuid/v5, or ends with one, as in gopkg.in/yaml.v3.
+var majorVersionElem = regexp.MustCompile(`(^|\.)v[0-9]+$`)
+
+// typeQualifier matches the package name qualifying a type in Go source, such as decimal in []decimal.Decimal.
+var typeQualifier = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.[A-Za-z_]`)
+
+// parseQualifiedType splits goType, a type name with the path of the package declaring it such as
+// github.com/google/uuid.UUID, into the import path and the type as it is referred to in generated code, such as
+// uuid.UUID. A type without an import path, which is declared in the generated package, is returned as it is.
+func parseQualifiedType(goType string) (importPath, pkgName, qualified string, err error) {
+	dot := strings.LastIndex(goType, ".")
+	if dot < 0 {
+		return "", "", goType, nil
+	}
+	importPath, name := goType[:dot], goType[dot+1:]
+	if importPath == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", "", fmt.Errorf("%q isn't a type name with an import path, such as github.com/google/uuid.UUID", goType)
+	}
+	pkgName = importPathName(importPath)
+	return importPath, pkgName, pkgName + "." + name, nil
+}
+
+// importPathName guesses the name of the package at importPath, which is usually the last element of the path, such
+// as uuid for github.com/google/uuid, but without a major version, as for github.com/gofrs/uuid/v5, and without any
+// prefix before a dot or dash, as for github.com/satori/go.uuid.
+func importPathName(importPath string) string {
+	elem := path.Base(importPath)
+	if majorVersionElem.MatchString(elem) && strings.HasPrefix(elem, "v") && path.Dir(importPath) != "." {
+		elem = path.Base(path.Dir(importPath))
+	}
+	elem = majorVersionElem.ReplaceAllString(elem, "")
+	if i := strings.LastIndexAny(elem, ".-"); i >= 0 {
+		elem = elem[i+1:]
+	}
+	return elem
+}
+
+// setFormatType makes goType, a type name with the path of the package declaring it as for parseQualifiedType, the
+// Go type for string properties with format.
+func (g *generator) setFormatType(format, goType string) error {
+	qualified, err := g.addQualifiedType(goType)
+	if err != nil {
+		return err
+	}
+	g.formatGoTypes[format] = qualified
+	return nil
+}
+
+// setTypeMapping makes goType, as for setFormatType, the Go type for scalar properties with format, whatever their
+// JSON type.
+func (g *generator) setTypeMapping(format, goType string) error {
+	qualified, err := g.addQualifiedType(goType)
+	if err != nil {
+		return err
+	}
+	g.typeMappings[format] = qualified
+	return nil
+}
+
+// addQualifiedType returns goType, a type name with the path of the package declaring it as for parseQualifiedType,
+// as it is referred to in generated code, and registers its package to be imported where it is used.
+func (g *generator) addQualifiedType(goType string) (string, error) {
+	importPath, pkgName, qualified, err := parseQualifiedType(goType)
+	if err != nil {
+		return "", err
+	}
+	if importPath != "" {
+		g.formatTypes[qualified] = importPath
+		g.importNames[importPath] = pkgName
+	}
+	return qualified, nil
+}
+
+// goTypeOverride returns the Go type that s pins with x-go-type, or "" if it has none. The type is used as it is, and
+// the package of x-go-import, if s has one, is registered to be imported where it is used, named as in the type, e.g.
+// decimal.Decimal with github.com/shopspring/decimal. Without x-go-import, the type can include its package's path as
+// for parseQualifiedType, such as github.com/shopspring/decimal.Decimal.
+func (g *generator) goTypeOverride(s *metaSchema, path string) string {
+	if s.GoType == "" {
+		return ""
+	}
+	if s.GoImport == "" {
+		if !strings.Contains(s.GoType, "/") {
+			return s.GoType
+		}
+		qualified, err := g.addQualifiedType(s.GoType)
+		if err != nil {
+			g.fail("x-go-type at %s: %s", path, err)
+		}
+		return qualified
+	}
+	pkgName := importPathName(s.GoImport)
+	if m := typeQualifier.FindStringSubmatch(s.GoType); m != nil {
+		pkgName = m[1]
+	}
+	g.importNames[s.GoImport] = pkgName
+	return s.GoType
+}
+
+// civilFormat is a string format whose values are only part of a timestamp, which time.Time can't unmarshal from
+// JSON on its own.
+type civilFormat struct {
+	// name is the name of the type generated for the format, before it is exported.
+	name string
+	// layout is the time layout of the format's values.
+	layout string
+	// desc describes the format's values in the type's comment.
+	desc string
+}
+
+// civilFormats are the formats that get a generated type wrapping time.Time, unless DateType, TimeType or
+// TypeMappings give them another type.
+var civilFormats = map[string]civilFormat{
+	"date": {name: "date", layout: "2006-01-02", desc: "a calendar date, such as 2006-01-02"},
+	"time": {name: "timeOfDay", layout: "15:04:05.999999999Z07:00", desc: "a time of day with its offset from UTC, such as 15:04:05Z"},
+}
+
+// civilTypeName returns the name of the type generated for format, one of civilFormats.
+func (g *generator) civilTypeName(format string) string {
+	return g.generateIdentifier(civilFormats[format].name, g.exportTypes())
+}
+
+// civilFormat returns the format whose generated type is named typeStr, if there is one.
+func (g *generator) civilFormat(typeStr string) (string, bool) {
+	for _, format := range g.civilTypes.Sorted() {
+		if typeStr == g.civilTypeName(format) {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// printCivilType prints the type for the values of format, one of civilFormats, which is a time.Time that is
+// marshalled in the format's layout.
+func (g *generator) printCivilType(buf *bytes.Buffer, format string) {
+	name := g.civilTypeName(format)
+	buf.WriteString(fmt.Sprintf("// %s is %s, as in the %s format of JSON Schema.\n", name, civilFormats[format].desc, format))
+	buf.WriteString(fmt.Sprintf("// It converts to and from time.Time, e.g. time.Time(v).\ntype %s time.Time\n", name))
+	printCivilMethods(buf, name, format)
+}
+
+// printCivilMethods prints the methods that marshal a type for format, one of civilFormats, as text in its layout,
+// which encoding/json uses for the type's JSON strings.
+func printCivilMethods(buf *bytes.Buffer, name, format string) {
+	layout := civilFormats[format].layout
+	buf.WriteString(fmt.Sprintf("\n// MarshalText formats the value as %s.\n", layout))
+	buf.WriteString(fmt.Sprintf("func (v %s) MarshalText() ([]byte, error) {\nreturn []byte(time.Time(v).Format(%q)), nil\n}\n", name, layout))
+	buf.WriteString(fmt.Sprintf("\n// UnmarshalText parses a value formatted as %s.\n", layout))
+	buf.WriteString(fmt.Sprintf("func (v *%s) UnmarshalText(text []byte) error {\n", name))
+	buf.WriteString(fmt.Sprintf("t, err := time.Parse(%q, string(text))\nif err != nil {\nreturn err\n}\n", layout))
+	buf.WriteString(fmt.Sprintf("*v = %s(t)\nreturn nil\n}\n", name))
+	buf.WriteString(fmt.Sprintf("\n// String returns the value formatted as %s.\n", layout))
+	buf.WriteString(fmt.Sprintf("func (v %s) String() string {\nreturn time.Time(v).Format(%q)\n}\n", name, layout))
+}
@@ -0,0 +1,437 @@
+package gen
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseQualifiedType(t *testing.T) {
+	Convey("Given Go types with and without import paths", t, func() {
+		cases := []struct{ goType, importPath, pkgName, qualified string }{
+			{"github.com/google/uuid.UUID", "github.com/google/uuid", "uuid", "uuid.UUID"},
+			{"github.com/gofrs/uuid/v5.UUID", "github.com/gofrs/uuid/v5", "uuid", "uuid.UUID"},
+			{"github.com/satori/go.uuid.UUID", "github.com/satori/go.uuid", "uuid", "uuid.UUID"},
+			{"gopkg.in/mgo.v2/bson.ObjectId", "gopkg.in/mgo.v2/bson", "bson", "bson.ObjectId"},
+			{"myUUID", "", "", "myUUID"},
+		}
+
+		Convey("Then each should be split into its import path and qualified type", func() {
+			for _, c := range cases {
+				importPath, pkgName, qualified, err := parseQualifiedType(c.goType)
+				So(err, ShouldBeNil)
+				So(importPath, ShouldEqual, c.importPath)
+				So(pkgName, ShouldEqual, c.pkgName)
+				So(qualified, ShouldEqual, c.qualified)
+			}
+		})
+	})
+
+	Convey("Given Go types without a type name", t, func() {
+		Convey("Then they should be rejected", func() {
+			for _, goType := range []string{"github.com/google/uuid.", ".UUID", "github.com/google/uuid"} {
+				_, _, _, err := parseQualifiedType(goType)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestUUIDType(t *testing.T) {
+	Convey("Given a schema with a uuid property", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "format": "uuid"},
+				"createdAt": {"type": "string", "format": "date-time"}
+			},
+			"required": ["id"]
+		}`
+
+		Convey("When we generate without --uuid-type", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the field should be a string", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ID string `json:\"id\"`")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --uuid-type=github.com/google/uuid.UUID", func() {
+			opts.UUIDType = "github.com/google/uuid.UUID"
+			src, err := generateFromString(schema)
+
+			Convey("Then the field should be a uuid.UUID and both packages should be imported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ID uuid.UUID `json:\"id\"`")
+				So(src, ShouldContainSubstring, "import (\n\t\"time\"\n\n\t\"github.com/google/uuid\"\n)\n")
+			})
+		})
+
+		Convey("When we generate with a uuid type whose package name differs from its path", func() {
+			opts.UUIDType = "github.com/satori/go.uuid.UUID"
+			src, err := generateFromString(schema)
+
+			Convey("Then the import should name the package", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ID uuid.UUID `json:\"id\"`")
+				So(src, ShouldContainSubstring, "\tuuid \"github.com/satori/go.uuid\"\n")
+			})
+		})
+
+		Convey("When we generate with a uuid type in the same package", func() {
+			opts.UUIDType = "myUUID"
+			src, err := generateFromString(schema)
+
+			Convey("Then the field should use it without an import", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "ID myUUID `json:\"id\"`")
+				So(typeCheck(src, "package main\n\ntype myUUID [16]byte\n"), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with an invalid uuid type", func() {
+			opts.UUIDType = "github.com/google/uuid"
+			_, err := generateFromString(schema)
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When we generate files with --uuid-type", func() {
+			files, err := GenerateFiles([]byte(schema), Options{SchemaName: "order", UUIDType: "github.com/gofrs/uuid/v5.UUID"})
+
+			Convey("Then the file using it should import it", func() {
+				So(err, ShouldBeNil)
+				So(string(files["order.go"]), ShouldContainSubstring, "\tuuid \"github.com/gofrs/uuid/v5\"\n")
+			})
+		})
+	})
+
+	Convey("Given a schema whose only uuid property is in an unreferenced definition", t, func() {
+		resetGenerator()
+		opts.UUIDType = "github.com/google/uuid.UUID"
+		opts.PruneTypes = true
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {"name": {"type": "string"}},
+			"definitions": {"audit": {"type": "object", "properties": {"by": {"type": "string", "format": "uuid"}}}}
+		}`)
+
+		Convey("Then the uuid package should not be imported", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldNotContainSubstring, "import")
+		})
+	})
+}
+
+func TestTypeMappings(t *testing.T) {
+	Convey("Given a schema with properties of custom and built-in formats", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"email": {"type": "string", "format": "email"},
+				"price": {"type": "number", "format": "decimal"},
+				"id": {"type": "string", "format": "uuid"},
+				"createdAt": {"type": "string", "format": "date-time"},
+				"tags": {"type": "array", "format": "decimal", "items": {"type": "string"}}
+			}
+		}`
+
+		Convey("When we generate with type mappings", func() {
+			opts.UUIDType = "github.com/gofrs/uuid/v5.UUID"
+			opts.TypeMappings = map[string]string{
+				"email":     "emailAddress",
+				"decimal":   "github.com/shopspring/decimal.Decimal",
+				"uuid":      "github.com/google/uuid.UUID",
+				"date-time": "string",
+			}
+			src, err := generateFromString(schema)
+
+			Convey("Then the properties should have the mapped types and their packages should be imported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Email emailAddress `json:\"email,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "Price decimal.Decimal `json:\"price,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "ID uuid.UUID `json:\"id,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "CreatedAt string `json:\"createdAt,omitempty\"`")
+				So(src, ShouldContainSubstring, "import (\n\t\"github.com/google/uuid\"\n\t\"github.com/shopspring/decimal\"\n)\n")
+			})
+
+			Convey("Then arrays should keep their types", func() {
+				So(compact(src), ShouldContainSubstring, "Tags []tag `json:\"tags,omitempty\"`")
+			})
+		})
+
+		Convey("When we generate with an invalid type mapping", func() {
+			opts.TypeMappings = map[string]string{"decimal": "github.com/shopspring/decimal"}
+			_, err := generateFromString(schema)
+
+			Convey("Then there should be an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "type mapping for decimal")
+			})
+		})
+	})
+}
+
+func TestGoTypeOverrides(t *testing.T) {
+	Convey("Given a schema with x-go-type on properties and a definition", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"price": {"type": "string", "x-go-type": "decimal.Decimal", "x-go-import": "github.com/shopspring/decimal"},
+				"id": {"type": "string", "format": "uuid", "x-go-type": "gofrsuuid.UUID", "x-go-import": "github.com/gofrs/uuid/v5"},
+				"payload": {"type": "object", "properties": {"a": {"type": "string"}}, "x-go-type": "json.RawMessage"},
+				"discount": {"type": ["string", "null"], "x-go-type": "github.com/shopspring/decimal.Decimal"},
+				"total": {"$ref": "#/definitions/amount"}
+			},
+			"definitions": {
+				"amount": {"type": "number", "x-go-type": "int64"}
+			}
+		}`
+
+		Convey("When we generate", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the properties should have the given types, and their packages should be imported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Price decimal.Decimal `json:\"price,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "ID gofrsuuid.UUID `json:\"id,omitempty\"`")
+				So(compact(src), ShouldContainSubstring, "Discount *decimal.Decimal `json:\"discount,omitempty\"`")
+				So(src, ShouldContainSubstring, "import (\n\t\"encoding/json\"\n\n\tgofrsuuid \"github.com/gofrs/uuid/v5\"\n\t\"github.com/shopspring/decimal\"\n)\n")
+			})
+
+			Convey("Then nothing should be inferred from their other keywords", func() {
+				So(compact(src), ShouldContainSubstring, "Payload json.RawMessage `json:\"payload,omitempty\"`")
+				So(src, ShouldNotContainSubstring, "type payload")
+			})
+
+			Convey("Then a definition should be a named type of the given type", func() {
+				So(src, ShouldContainSubstring, "type amount int64\n")
+				So(compact(src), ShouldContainSubstring, "Total amount `json:\"total,omitempty\"`")
+			})
+		})
+	})
+}
+
+func TestDateAndTimeFormats(t *testing.T) {
+	Convey("Given a schema with date and time properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"birthday": {"type": "string", "format": "date"},
+				"opensAt": {"type": "string", "format": "time"}
+			},
+			"required": ["birthday", "opensAt"]
+		}`
+
+		Convey("When we generate without --date-type or --time-type", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then the fields should have generated types wrapping time.Time", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Birthday date `json:\"birthday\"`")
+				So(compact(src), ShouldContainSubstring, "OpensAt timeOfDay `json:\"opensAt\"`")
+				So(src, ShouldContainSubstring, "type date time.Time\n")
+				So(src, ShouldContainSubstring, "type timeOfDay time.Time\n")
+				So(src, ShouldContainSubstring, "import \"time\"\n")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then the types should parse and format their layouts", func() {
+				mainSrc := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+func main() {
+	var s schema
+	err := json.Unmarshal([]byte(` + "`" + `{"birthday": "2024-02-29", "opensAt": "09:30:00.5+02:00"}` + "`" + `), &s)
+	fmt.Println(err, time.Time(s.Birthday).Weekday(), s.OpensAt)
+	out, err := json.Marshal(s)
+	fmt.Println(string(out), err)
+	fmt.Println(json.Unmarshal([]byte(` + "`" + `{"birthday": "2024-02-30"}` + "`" + `), &s) != nil)
+}
+`
+				out, err := runGenerated(src, mainSrc)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "<nil> Thursday 09:30:00.5+02:00\n"+
+					`{"birthday":"2024-02-29","opensAt":"09:30:00.5+02:00"}`+" <nil>\ntrue\n")
+			})
+		})
+
+		Convey("When we opt in to time.Time with --date-type and --time-type", func() {
+			opts.DateType = "time.Time"
+			opts.TimeType = "time.Time"
+			src, err := generateFromString(schema)
+
+			Convey("Then both fields should be time.Time and time should be imported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Birthday time.Time `json:\"birthday\"`")
+				So(compact(src), ShouldContainSubstring, "OpensAt time.Time `json:\"opensAt\"`")
+				So(src, ShouldContainSubstring, "import \"time\"\n")
+				So(typeCheck(src), ShouldBeNil)
+			})
+		})
+
+		Convey("When we generate with --date-type and --time-type", func() {
+			opts.DateType = "cloud.google.com/go/civil.Date"
+			opts.TimeType = "cloud.google.com/go/civil.Time"
+			src, err := generateFromString(schema)
+
+			Convey("Then the fields should use them and only their package should be imported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Birthday civil.Date `json:\"birthday\"`")
+				So(compact(src), ShouldContainSubstring, "OpensAt civil.Time `json:\"opensAt\"`")
+				So(src, ShouldContainSubstring, "import \"cloud.google.com/go/civil\"\n")
+			})
+		})
+	})
+
+	Convey("Given a definition with format date", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {"due": {"$ref": "#/definitions/dueDate"}},
+			"definitions": {"dueDate": {"type": "string", "format": "date"}}
+		}`)
+
+		Convey("Then its type should have the date methods, which defined types don't inherit", func() {
+			So(err, ShouldBeNil)
+			So(src, ShouldContainSubstring, "type dueDate date\n")
+			So(src, ShouldContainSubstring, "func (v dueDate) MarshalText() ([]byte, error) {")
+			So(src, ShouldContainSubstring, "func (v *dueDate) UnmarshalText(text []byte) error {")
+			So(typeCheck(src), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a schema with an integer property with format date", t, func() {
+		resetGenerator()
+		src, err := generateFromString(`{"type": "object", "properties": {"day": {"type": "integer", "format": "date"}}}`)
+
+		Convey("Then the field should keep its JSON type", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "Day int `json:\"day,omitempty\"`")
+		})
+	})
+}
+
+func TestIntegerFormats(t *testing.T) {
+	Convey("Given a schema with integer properties with formats", t, func() {
+		resetGenerator()
+		opts.GenSQL = true
+		src, err := generateFromString(`{
+			"type": "object",
+			"properties": {
+				"count": {"type": "integer"},
+				"id": {"type": "integer", "format": "int64"},
+				"port": {"type": "integer", "format": "int32"},
+				"size": {"type": "integer", "format": "uint32"},
+				"hash": {"type": "integer", "format": "uint64"},
+				"ratio": {"type": "number", "format": "int64"},
+				"priority": {"type": "integer", "format": "int32", "enum": [1, 2]}
+			},
+			"definitions": {
+				"version": {"type": "integer", "format": "int64"},
+				"checksum": {"type": "integer", "format": "uint64"}
+			}
+		}`)
+
+		Convey("Then each field should have the sized type of its format", func() {
+			So(err, ShouldBeNil)
+			So(compact(src), ShouldContainSubstring, "Count int `json:\"count,omitempty\"`")
+			So(compact(src), ShouldContainSubstring, "ID int64 `json:\"id,omitempty\"`")
+			So(compact(src), ShouldContainSubstring, "Port int32 `json:\"port,omitempty\"`")
+			So(compact(src), ShouldContainSubstring, "Size uint32 `json:\"size,omitempty\"`")
+			So(compact(src), ShouldContainSubstring, "Hash uint64 `json:\"hash,omitempty\"`")
+			So(compact(src), ShouldContainSubstring, "Ratio float64 `json:\"ratio,omitempty\"`")
+			So(typeCheck(src), ShouldBeNil)
+		})
+
+		Convey("Then an enum should keep its constants", func() {
+			So(src, ShouldContainSubstring, "type priority int32")
+			So(compact(src), ShouldContainSubstring, "priority1 priority = 1")
+		})
+
+		Convey("Then SQL methods should convert from int64, except for uint64", func() {
+			So(src, ShouldContainSubstring, "func (v *version) Scan(")
+			So(src, ShouldNotContainSubstring, "func (v *checksum) Scan(")
+		})
+	})
+}
+
+func TestNumberType(t *testing.T) {
+	Convey("Given a schema with number properties", t, func() {
+		resetGenerator()
+		schema := `{
+			"type": "object",
+			"properties": {
+				"amount": {"type": "number", "minimum": 0},
+				"discount": {"type": ["number", "null"]},
+				"quantity": {"type": "integer", "minimum": 1}
+			},
+			"required": ["amount", "quantity"]
+		}`
+
+		Convey("When we generate with the default number type", func() {
+			src, err := generateFromString(schema)
+
+			Convey("Then numbers should be float64 without imports", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Amount float64 `json:\"amount\"`")
+				So(src, ShouldNotContainSubstring, "import")
+			})
+		})
+
+		Convey("When we generate with --number-type=json.Number", func() {
+			opts.NumberType = NumberTypeJSONNumber
+			opts.ValidateTags = true
+			src, err := generateFromString(schema)
+
+			Convey("Then numbers should be json.Number and encoding/json should be imported", func() {
+				So(err, ShouldBeNil)
+				So(compact(src), ShouldContainSubstring, "Amount json.Number `json:\"amount\"`")
+				So(compact(src), ShouldContainSubstring, "Discount *json.Number `json:\"discount,omitempty\"`")
+				So(src, ShouldContainSubstring, "import \"encoding/json\"\n")
+				So(typeCheck(src), ShouldBeNil)
+			})
+
+			Convey("Then integers should be unchanged", func() {
+				So(compact(src), ShouldContainSubstring, "Quantity int `json:\"quantity\" validate:\"min=1\"`")
+			})
+
+			Convey("Then numbers should not get validate tags, which would check their length", func() {
+				So(src, ShouldNotContainSubstring, "min=0")
+			})
+
+			Convey("Then a value should round-trip without losing precision", func() {
+				out, err := runGenerated(src, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var v schema
+	if err := json.Unmarshal([]byte(`+"`"+`{"amount": 12345678901234567.89, "quantity": 1}`+"`"+`), &v); err != nil {
+		panic(err)
+	}
+	fmt.Println(v.Amount)
+}
+`)
+				So(err, ShouldBeNil)
+				So(out, ShouldEqual, "12345678901234567.89\n")
+			})
+		})
+	})
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/idubinskiy/schematyper/gen"
+	"github.com/idubinskiy/schematyper/stringset"
+)
+
+var (
+	outToStdout        = kingpin.Flag("console", "output to console instead of file").Default("false").Short('c').Bool()
+	outputFile         = kingpin.Flag("out-file", "filename for output; default is <schema>_schematype.go").Short('o').String()
+	packageName        = kingpin.Flag("package", `package name for generated file; default is "main"`).Default("main").String()
+	rootTypeName       = kingpin.Flag("root-type", `name of root type; default is generated from the filename`).String()
+	unexported         = kingpin.Flag("unexported", "generate unexported types even if the package isn't main").Default("false").Bool()
+	typeNamesPrefix    = kingpin.Flag("prefix", `prefix for non-root types`).String()
+	maxNameLength      = kingpin.Flag("max-name-length", "length that the names of non-root types are abbreviated to, keeping their start and ending with a hash of the whole name; 0 for no limit").Default("0").Int()
+	ptrForOmit         = kingpin.Flag("ptr-for-omit", "use a pointer to a struct for an object property that is represented as a struct if the property is not required (i.e., has omitempty tag)").Default("false").Bool()
+	emitPtrHelpers     = kingpin.Flag("emit-pointer-helpers", "emit a generic Ptr function for constructing pointers to scalar values (requires Go 1.18+)").Default("false").Bool()
+	useAny             = kingpin.Flag("use-any", "write the empty interface as any instead of interface{} (requires Go 1.18+)").Default("false").Bool()
+	avroInput          = kingpin.Flag("avro", "treat the input as an Avro schema (.avsc) instead of a JSON schema").Default("false").Bool()
+	mapType            = kingpin.Flag("map-type", "type for objects with additionalProperties: map, or ordered for a generated map type that keeps key order through JSON (requires Go 1.18+)").Default(gen.MapTypeMap).Enum(gen.MapTypeMap, gen.MapTypeOrdered)
+	genBuilders        = kingpin.Flag("gen-builder", "generate a builder type for each struct type, taking required fields in its constructor and starting from the defaults of the others; Build returns an error if a required field is nil").Default("false").Bool()
+	maxInlineDepth     = kingpin.Flag("max-inline-depth", "how deeply objects can be nested in the root schema or a definition and still get a struct type of their own; deeper ones are map[string]interface{}; 0 for no limit").Default("0").Int()
+	pruneTypes         = kingpin.Flag("prune-unreferenced", "omit types that are not referenced, directly or indirectly, by the root type").Default("false").Bool()
+	keepTypes          = kingpin.Flag("keep", "comma-separated names of types to keep, along with the types they reference, when pruning unreferenced types").String()
+	onlyTypes          = kingpin.Flag("only", "comma-separated names of the only types to generate, along with the types they reference; the root type is left out unless it is named").String()
+	enumMarshalCheck   = kingpin.Flag("enum-marshal-check", "generate a MarshalJSON method for enum types that returns an error for values that are not one of the enum's constants").Default("false").Bool()
+	enumValidation     = kingpin.Flag("enum-validation", "generate an IsValid method for enum types and a function returning all of their constants").Default("false").Bool()
+	fieldSort          = kingpin.Flag("field-sort", "order of struct fields: name, required-first (required fields first, each group sorted by name), or schema (also schema-order) for the order of the properties in the schema").Default(gen.FieldSortName).Enum(gen.FieldSortName, gen.FieldSortRequiredFirst, gen.FieldSortSchema, gen.FieldSortSchemaOrder)
+	enumStringer       = kingpin.Flag("enum-stringer", "generate a String method for enum types that returns the name of a constant's value, from x-enum-varnames if the schema has it").Default("false").Bool()
+	enumErrors         = kingpin.Flag("enum-errors", "generate an Error method for string enum types that are named like errors or have x-go-error set").Default("false").Bool()
+	unexportPattern    = kingpin.Flag("unexport-pattern", "regular expression for property names that should be unexported fields; types with such fields get JSON methods that include them").Regexp()
+	avoidBuiltinShadow = kingpin.Flag("avoid-builtin-shadow", `add a "Type" suffix to type names that are lowercase predeclared identifiers or keywords such as error, len or map`).Default("false").Bool()
+	genSQL             = kingpin.Flag("gen-sql", "generate Scan and Value methods for named scalar types so they implement sql.Scanner and driver.Valuer").Default("false").Bool()
+	ndjsonDecoder      = kingpin.Flag("root-type-is-slice-alias", "generate a function that decodes newline-delimited JSON into a slice of records, for schemas describing a stream").Default("false").Bool()
+	reverseType        = kingpin.Flag("reverse", "generate a JSON schema for the named Go type instead, reading the package containing the input Go file").PlaceHolder("TYPE").String()
+	recursionStrategy  = kingpin.Flag("recursion-strategy", "type for fields that would make a struct contain itself: pointer, or rawmessage for a json.RawMessage to decode on demand").Default(gen.RecursionPointer).Enum(gen.RecursionPointer, gen.RecursionRawMessage)
+	redactPasswords    = kingpin.Flag("redact-passwords", "use a string type that hides its value when formatted, e.g. in logs, for properties with format password").Default("false").Bool()
+	dryRun             = kingpin.Flag("dry-run", "print a summary of the types that would be generated to stderr instead of writing them").Default("false").Bool()
+	allOfEmbed         = kingpin.Flag("allof-embed", "embed the types of $ref members of allOf instead of copying their fields").Default("false").Bool()
+	oneOfInterfaces    = kingpin.Flag("oneof-interfaces", "generate oneOf as an interface that its variants implement instead of interface{}; structs only unmarshal it if it has a discriminator").Default("false").Bool()
+	httpTimeout        = kingpin.Flag("http-timeout", "timeout for fetching the input from an http or https URL").Default("30s").Duration()
+	noOmitEmpty        = kingpin.Flag("no-omitempty", "never add omitempty to json tags, so zero values of optional fields are marshalled").Default("false").Bool()
+	tags               = kingpin.Flag("tags", "comma-separated libraries, such as json and yaml, whose struct tags are set to the property name").Default("json").String()
+	jsonTagCase        = kingpin.Flag("json-tag-case", "case of the property names in struct tags: preserve, snake for snake_case, or camel for camelCase").Default(gen.JSONTagCasePreserve).Enum(gen.JSONTagCasePreserve, gen.JSONTagCaseSnake, gen.JSONTagCaseCamel)
+	pointers           = kingpin.Flag("pointers", "which fields are pointers: nullable, optional for fields that are nullable or not required, or none").Default(gen.PointersNullable).Enum(gen.PointersNullable, gen.PointersOptional, gen.PointersNone)
+	refBaseDir         = kingpin.Flag("ref-base-dir", "directory that $ref paths to other schema files are resolved against; default is the input file's directory").String()
+	validateTags       = kingpin.Flag("validate-tags", "add go-playground/validator validate tags for minLength, maxLength, minimum, maximum, minItems and maxItems").Default("false").Bool()
+	summary            = kingpin.Flag("summary", "print the number of types that would be generated, deferred types resolved and name collisions to stderr instead of writing them").Default("false").Bool()
+	preserveOrder      = kingpin.Flag("preserve-order", "keep struct fields in the order of the properties in the schema; same as --field-sort=schema").Default("false").Bool()
+	bsonTags           = kingpin.Flag("bson-tags", "add bson tags for the MongoDB driver, with the property names lowercased").Default("false").Bool()
+	nullMethods        = kingpin.Flag("null-methods", "generate a MarshalJSON method for structs with optional nullable fields that writes null for those that are nil instead of leaving them out").Default("false").Bool()
+	strictUnmarshal    = kingpin.Flag("strict-unmarshal", "generate an UnmarshalJSON method that rejects unknown properties for structs whose schemas have additionalProperties false").Default("false").Bool()
+	initialisms        = kingpin.Flag("initialisms", "comma-separated words, such as SKU, to keep in uppercase in identifiers in addition to the default ones").String()
+	noDefInitialisms   = kingpin.Flag("no-default-initialisms", "only keep the words from --initialisms in uppercase, not the default ones such as ID and URL").Default("false").Bool()
+	splitFiles         = kingpin.Flag("split-files", "write each type to its own file named after it, e.g. user_id.go, instead of a single file").Default("false").Bool()
+	definitionsPackage = kingpin.Flag("definitions-package", "import path of a package, such as example.com/service/types, to write the types of definitions to, in a directory named after it; requires --split-files").String()
+	outDir             = kingpin.Flag("out-dir", "directory for output files; default is the current directory").String()
+	uuidType           = kingpin.Flag("uuid-type", "Go type with its import path, such as github.com/google/uuid.UUID, for string properties with format uuid; default is string").String()
+	typeMappings       = kingpin.Flag("type-mappings", "comma-separated format=type pairs, such as email=string,decimal=github.com/shopspring/decimal.Decimal, giving the Go type with its import path for properties with each format").String()
+	accessComments     = kingpin.Flag("access-comments", "note readOnly and writeOnly properties in their fields' comments").Default("false").Bool()
+	accessTags         = kingpin.Flag("access-tags", `add an access tag, "read" or "write", to the fields of readOnly and writeOnly properties`).Default("false").Bool()
+	numberType         = kingpin.Flag("number-type", "type for numbers: float64, or json.Number to keep their precision").Default(gen.NumberTypeFloat64).Enum(gen.NumberTypeFloat64, gen.NumberTypeJSONNumber)
+	dateType           = kingpin.Flag("date-type", "Go type with its import path, such as cloud.google.com/go/civil.Date, for string properties with format date; default is a generated date type wrapping time.Time").String()
+	timeType           = kingpin.Flag("time-type", "Go type with its import path, such as cloud.google.com/go/civil.Time, for string properties with format time; default is a generated timeOfDay type wrapping time.Time").String()
+	constructors       = kingpin.Flag("constructors", "generate a New function for each struct type that sets the fields with a default in the schema to it").Default("false").Bool()
+	yamlInput          = kingpin.Flag("yaml-input", "read the input schemas as YAML instead of JSON").Default("false").Bool()
+	commentWidth       = kingpin.Flag("field-comment-width", "width that type and field comments from descriptions are wrapped at, or -1 to not wrap them").Default("80").Int()
+	noComments         = kingpin.Flag("no-comments", "leave out the comments of types and fields, such as those from descriptions").Default("false").Bool()
+	noBanner           = kingpin.Flag("no-banner", "leave out the line saying that the file is generated").Default("false").Bool()
+	banner             = kingpin.Flag("banner", `text of the line saying that the file is generated; default is "Code generated by schematyper. DO NOT EDIT."`).String()
+	fileComment        = kingpin.Flag("file-comment", "comment, such as //nolint:all, to add after the package clause of generated files").String()
+	buildTags          = kingpin.Flag("build-tags", `build constraint expression for a //go:build line at the top of generated files, e.g. "generated"`).String()
+	strictKeywords     = kingpin.Flag("strict", "fail on schema keywords that would change the generated types but are not supported, such as if and not, instead of warning about them").Default("false").Bool()
+	genTest            = kingpin.Flag("gen-test", "also write a test, named after the output file with a _test suffix, that marshals the zero value of the root type to JSON and unmarshals it back").Default("false").Bool()
+	noFormat           = kingpin.Flag("no-format", "write the generated source without formatting it with gofmt, e.g. to see why it doesn't compile").Default("false").Bool()
+	inputFiles         = kingpin.Arg("input", `files or http(s) URLs containing valid JSON schemas, or "-" for stdin; each gets its own output file`).Required().Strings()
+)
+
+func main() {
+	kingpin.MustParse(kingpin.CommandLine.Parse(stdinArgs(os.Args[1:], valueFlags(kingpin.CommandLine))))
+	if err := checkInputs(*inputFiles); err != nil {
+		log.Fatalln(err)
+	}
+	mappings, err := parseTypeMappings(*typeMappings)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *reverseType != "" {
+		input := (*inputFiles)[0]
+		schemaJSON, err := gen.ReverseSchema(filepath.Dir(input), *reverseType)
+		if err != nil {
+			log.Fatalln("Error building schema:", err)
+		}
+		writeOutput(schemaJSON, strings.ToLower(*reverseType)+".json")
+		return
+	}
+
+	opts := gen.Options{
+		PackageName:          *packageName,
+		RootTypeName:         *rootTypeName,
+		Unexported:           *unexported,
+		TypeNamesPrefix:      *typeNamesPrefix,
+		MaxNameLength:        *maxNameLength,
+		PtrForOmit:           *ptrForOmit,
+		EmitPtrHelpers:       *emitPtrHelpers,
+		UseAny:               *useAny,
+		Avro:                 *avroInput,
+		MapType:              *mapType,
+		GenBuilders:          *genBuilders,
+		MaxInlineDepth:       *maxInlineDepth,
+		PruneTypes:           *pruneTypes,
+		KeepTypes:            splitList(*keepTypes),
+		OnlyTypes:            splitList(*onlyTypes),
+		EnumMarshalCheck:     *enumMarshalCheck,
+		EnumValidation:       *enumValidation,
+		EnumStringer:         *enumStringer,
+		FieldSort:            *fieldSort,
+		EnumErrors:           *enumErrors,
+		UnexportPattern:      *unexportPattern,
+		AvoidBuiltinShadow:   *avoidBuiltinShadow,
+		GenSQL:               *genSQL,
+		NDJSONDecoder:        *ndjsonDecoder,
+		RecursionStrategy:    *recursionStrategy,
+		RedactPasswords:      *redactPasswords,
+		AllOfEmbed:           *allOfEmbed,
+		OneOfInterfaces:      *oneOfInterfaces,
+		NoOmitEmpty:          *noOmitEmpty,
+		Tags:                 splitList(*tags),
+		JSONTagCase:          *jsonTagCase,
+		Pointers:             *pointers,
+		RefBaseDir:           *refBaseDir,
+		ValidateTags:         *validateTags,
+		BSONTags:             *bsonTags,
+		StrictUnmarshal:      *strictUnmarshal,
+		NullMethods:          *nullMethods,
+		Initialisms:          splitList(*initialisms),
+		NoDefaultInitialisms: *noDefInitialisms,
+		UUIDType:             *uuidType,
+		TypeMappings:         mappings,
+		AccessComments:       *accessComments,
+		AccessTags:           *accessTags,
+		NumberType:           *numberType,
+		DateType:             *dateType,
+		TimeType:             *timeType,
+		Constructors:         *constructors,
+		CommentWidth:         *commentWidth,
+		NoComments:           *noComments,
+		NoBanner:             *noBanner,
+		Banner:               *banner,
+		FileComment:          *fileComment,
+		BuildTags:            *buildTags,
+		StrictKeywords:       *strictKeywords,
+		NoFormat:             *noFormat,
+		DefinitionsPackage:   *definitionsPackage,
+	}
+	if *preserveOrder {
+		opts.FieldSort = gen.FieldSortSchema
+	}
+	if *dryRun {
+		opts.Summary = os.Stderr
+	}
+	if *summary {
+		opts.Stats = os.Stderr
+	}
+	for _, input := range *inputFiles {
+		if (*dryRun || *summary) && len(*inputFiles) > 1 {
+			fmt.Fprintf(os.Stderr, "%s:\n", input)
+		}
+		generateInput(input, opts)
+	}
+}
+
+// checkInputs returns an error if the flags can't be used with the given inputs: --out-file and --root-type name a
+// single output, --reverse reads the package of a single Go file, and --split-files writes several files, which
+// --definitions-package needs. --gen-test writes a file beside the output, so it needs one.
+func checkInputs(inputs []string) error {
+	if len(inputs) > 1 {
+		switch {
+		case *outputFile != "":
+			return errors.New("--out-file can only be used with a single input")
+		case *rootTypeName != "":
+			return errors.New("--root-type can only be used with a single input")
+		case *reverseType != "":
+			return errors.New("--reverse can only be used with a single input")
+		}
+	}
+	if *splitFiles {
+		switch {
+		case *outputFile != "":
+			return errors.New("--out-file names a single file, so it can't be used with --split-files")
+		case *outToStdout:
+			return errors.New("--split-files writes files, so it can't be used with --console")
+		}
+	}
+	if *genTest && *outToStdout {
+		return errors.New("--gen-test writes a test file, so it can't be used with --console")
+	}
+	if *definitionsPackage != "" && !*splitFiles {
+		return errors.New("--definitions-package writes two packages, so it needs --split-files")
+	}
+	stdinCount := 0
+	for _, input := range inputs {
+		if input == stdinInput {
+			stdinCount++
+		}
+		if *reverseType != "" && (input == stdinInput || inputURL(input) != nil) {
+			return errors.New("--reverse reads a Go package, so the input must be a file in it")
+		}
+	}
+	if stdinCount > 1 {
+		return errors.New(`stdin ("-") can only be read once`)
+	}
+	return nil
+}
+
+// generateInput generates the types for the schema in input, which is read with readInput, and writes them to the
+// output file named after its root type. Each input is generated separately, so inputs don't share types.
+func generateInput(input string, opts gen.Options) {
+	file, err := readInput(input, os.Stdin)
+	if err != nil {
+		log.Fatalf("Error reading %s: %s\n", input, err)
+	}
+	if *yamlInput {
+		if file, err = yamlToJSON(file); err != nil {
+			log.Fatalf("Error parsing YAML in %s: %s\n", input, err)
+		}
+	}
+
+	// without --root-type, the root type of a JSON schema is named after the schema, by its $id if it has one; Avro
+	// schemas name their own
+	schemaName := inputSchemaName(input)
+	if idName := gen.IDName(file); idName != "" && !opts.Avro {
+		schemaName = idName
+	}
+	opts.SchemaName = schemaName
+	if opts.RefBaseDir == "" && input != stdinInput && inputURL(input) == nil {
+		opts.RefBaseDir = filepath.Dir(input)
+	}
+
+	rootType := opts.RootTypeName
+	if rootType == "" {
+		rootType = schemaName
+		if !opts.Avro {
+			// initialisms don't matter, since the name is lowercased
+			rootType = gen.Identifier(schemaName, opts.PackageName != "main" && !opts.Unexported)
+		}
+	}
+	outputFileName := fmt.Sprintf("%s_schematype.go", strings.ToLower(rootType))
+	if *outputFile != "" {
+		outputFileName = *outputFile
+	}
+
+	if *splitFiles && !*dryRun && !*summary {
+		files, err := gen.GenerateFiles(file, opts)
+		if err != nil {
+			log.Fatalf("Error generating types for %s: %s\n", input, err)
+		}
+		for fileName, src := range files {
+			writeOutput(src, fileName)
+		}
+		if *genTest {
+			writeTest(file, opts, outputFileName)
+		}
+		return
+	}
+
+	// the summaries describe the types, whichever package they are written to
+	opts.DefinitionsPackage = ""
+	formattedSrc, err := gen.Generate(file, opts)
+	if err != nil {
+		if formattedSrc != nil {
+			fmt.Println(string(formattedSrc))
+		}
+		log.Fatalf("Error generating types for %s: %s\n", input, err)
+	}
+	if *dryRun || *summary {
+		return
+	}
+
+	writeOutput(formattedSrc, outputFileName)
+	if *genTest {
+		writeTest(file, opts, outputFileName)
+	}
+}
+
+// writeTest writes the test of --gen-test for the types generated from file to a file named after outputFileName,
+// e.g. user_schematype_test.go for user_schematype.go.
+func writeTest(file []byte, opts gen.Options, outputFileName string) {
+	src, err := gen.GenerateTest(file, opts)
+	if err != nil {
+		log.Fatalf("Error generating test for %s: %s\n", outputFileName, err)
+	}
+	writeFile(src, strings.TrimSuffix(outputFileName, ".go")+"_test.go")
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseTypeMappings parses the comma-separated format=type pairs of --type-mappings.
+func parseTypeMappings(list string) (map[string]string, error) {
+	mappings := make(map[string]string)
+	for _, item := range splitList(list) {
+		eq := strings.Index(item, "=")
+		if eq <= 0 || eq == len(item)-1 {
+			return nil, fmt.Errorf("--type-mappings: %q isn't a format=type pair", item)
+		}
+		mappings[strings.TrimSpace(item[:eq])] = strings.TrimSpace(item[eq+1:])
+	}
+	return mappings, nil
+}
+
+// stdinInput is the input argument for reading the schema from stdin.
+const stdinInput = "-"
+
+// stdinArgs moves a "-" input argument after "--", since kingpin would otherwise parse it as a short flag. A value
+// starting with "-", such as "-", that follows one of valueFlags, the flags that take a value such as -o, is joined to
+// its flag, e.g. as -o- or --out-file=-, for the same reason.
+func stdinArgs(args []string, valueFlags stringset.StringSet) []string {
+	parseArgs := make([]string, 0, len(args)+1)
+	stdin := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args
+		}
+		if arg == stdinInput {
+			stdin = true
+			continue
+		}
+		if takesValue(arg, valueFlags) && i+1 < len(args) {
+			i++
+			if value := args[i]; strings.HasPrefix(value, "-") {
+				arg = joinFlagValue(arg, value)
+			} else {
+				parseArgs = append(parseArgs, arg)
+				arg = value
+			}
+		}
+		parseArgs = append(parseArgs, arg)
+	}
+	if stdin {
+		parseArgs = append(parseArgs, "--", stdinInput)
+	}
+	return parseArgs
+}
+
+// joinFlagValue returns flag, which takes a value, with value attached as a single argument: after "=" for a long
+// flag, or directly after a short one.
+func joinFlagValue(flag, value string) string {
+	if strings.HasPrefix(flag, "--") {
+		return flag + "=" + value
+	}
+	return flag + value
+}
+
+// takesValue returns true if arg is a flag in valueFlags without its value, such as -o or --out-file, or short flags
+// ending with one, such as -co, so that the next argument is its value.
+func takesValue(arg string, valueFlags stringset.StringSet) bool {
+	if strings.HasPrefix(arg, "--") {
+		return valueFlags.Has(arg)
+	}
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	for i, short := range arg[1:] {
+		if valueFlags.Has("-" + string(short)) {
+			// the rest of a group of short flags after one that takes a value is that value
+			return i == len(arg)-2
+		}
+	}
+	return false
+}
+
+// valueFlags returns the names, with their dashes, of the flags of app that take a value.
+func valueFlags(app *kingpin.Application) stringset.StringSet {
+	names := stringset.New()
+	for _, flag := range app.Model().Flags {
+		if flag.IsBoolFlag() {
+			continue
+		}
+		names.Add("--" + flag.Name)
+		if flag.Short != 0 {
+			names.Add("-" + string(flag.Short))
+		}
+	}
+	return names
+}
+
+// inputURL returns the input as a URL if it is an http or https URL, or nil otherwise.
+func inputURL(input string) *url.URL {
+	u, err := url.Parse(input)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+	return u
+}
+
+// readInput returns the contents of the input, which is a file, an http or https URL, or stdin if input is "-".
+func readInput(input string, stdin io.Reader) ([]byte, error) {
+	if input == stdinInput {
+		return ioutil.ReadAll(stdin)
+	}
+	if inputURL(input) != nil {
+		return fetchInput(input, *httpTimeout)
+	}
+	return ioutil.ReadFile(input)
+}
+
+// fetchInput returns the body of the response to a GET request for the URL input. Responses other than 200 OK are
+// errors.
+func fetchInput(input string, timeout time.Duration) ([]byte, error) {
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Get(input)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", input, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// yamlToJSON converts a schema written in YAML to JSON, keeping the order of map keys so that --preserve-order still
+// works. Map keys must be strings, since JSON object keys are.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var schema yaml.MapSlice
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, schema, "#"); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes the JSON encoding of the YAML value v to buf; path locates v in the schema for errors.
+func writeJSON(buf *bytes.Buffer, v interface{}, path string) error {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		buf.WriteByte('{')
+		for i, item := range v {
+			key, ok := item.Key.(string)
+			if !ok {
+				return fmt.Errorf("map key %v at %s is not a string", item.Key, path)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			keyJSON, _ := json.Marshal(key)
+			buf.Write(keyJSON)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, item.Value, path+"/"+key); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		valueJSON, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("value at %s: %s", path, err)
+		}
+		buf.Write(valueJSON)
+	}
+	return nil
+}
+
+// inputSchemaName returns the name of the schema for the default root type and output file name, which is the base
+// name of the input file or of the input URL's path without extensions.
+func inputSchemaName(input string) string {
+	if input == stdinInput {
+		return "schema"
+	}
+	if u := inputURL(input); u != nil {
+		input = path.Base(u.Path)
+	}
+	return strings.Split(filepath.Base(input), ".")[0]
+}
+
+// writeOutput writes output to the console or to the output file, which defaults to defaultFileName, in --out-dir.
+func writeOutput(output []byte, defaultFileName string) {
+	if *outToStdout {
+		fmt.Print(string(output))
+		return
+	}
+
+	outputFileName := *outputFile
+	if outputFileName == "" {
+		outputFileName = defaultFileName
+	}
+	writeFile(output, outputFileName)
+}
+
+// writeFile writes output to the file fileName in --out-dir.
+func writeFile(output []byte, fileName string) {
+	outputFileName := filepath.Join(*outDir, fileName)
+	// the directory may be --out-dir, or the directory of the files of --definitions-package in it
+	if dir := filepath.Dir(outputFileName); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalf("Error creating %s: %s\n", dir, err)
+		}
+	}
+	if err := ioutil.WriteFile(outputFileName, output, 0644); err != nil {
+		log.Fatalf("Error writing to %s: %s\n", outputFileName, err)
+	}
+}
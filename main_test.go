@@ -0,0 +1,202 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/idubinskiy/schematyper/stringset"
+)
+
+func TestStdinInput(t *testing.T) {
+	Convey("Given a \"-\" input argument", t, func() {
+		flags := stringset.New("-o", "--out-file", "--package")
+
+		Convey("Then it should be moved after \"--\" for kingpin", func() {
+			So(stdinArgs([]string{"-c", "-", "--package=schemas"}, flags), ShouldResemble, []string{"-c", "--package=schemas", "--", "-"})
+			So(stdinArgs([]string{"-c", "--", "-"}, flags), ShouldResemble, []string{"-c", "--", "-"})
+			So(stdinArgs([]string{"-c", "schema.json"}, flags), ShouldResemble, []string{"-c", "schema.json"})
+		})
+
+		Convey("Then a \"-\" that is the value of a flag should be joined to it", func() {
+			So(stdinArgs([]string{"-o", "-", "schema.json"}, flags), ShouldResemble, []string{"-o-", "schema.json"})
+			So(stdinArgs([]string{"--out-file", "-", "-"}, flags), ShouldResemble, []string{"--out-file=-", "--", "-"})
+			So(stdinArgs([]string{"-co", "-", "--package", "schemas", "-"}, flags), ShouldResemble, []string{"-co-", "--package", "schemas", "--", "-"})
+			So(stdinArgs([]string{"-ofile.go", "-"}, flags), ShouldResemble, []string{"-ofile.go", "--", "-"})
+		})
+
+		Convey("Then kingpin should parse the rewritten arguments", func() {
+			for _, c := range []struct {
+				args    []string
+				console bool
+				input   string
+			}{
+				{[]string{"-o", "-", "schema.json"}, false, "schema.json"},
+				{[]string{"--out-file", "-", "schema.json"}, false, "schema.json"},
+				{[]string{"-co", "-", "schema.json"}, true, "schema.json"},
+				{[]string{"-o", "-", "-"}, false, "-"},
+				{[]string{"schema.json", "--out-file", "-"}, false, "schema.json"},
+			} {
+				app := kingpin.New("schematyper", "")
+				console := app.Flag("console", "").Short('c').Bool()
+				outFile := app.Flag("out-file", "").Short('o').String()
+				inputs := app.Arg("input", "").Strings()
+				_, err := app.Parse(stdinArgs(c.args, valueFlags(app)))
+				So(err, ShouldBeNil)
+				So(*outFile, ShouldEqual, "-")
+				So(*console, ShouldEqual, c.console)
+				So(*inputs, ShouldResemble, []string{c.input})
+			}
+		})
+
+		Convey("Then the schema should be read from stdin", func() {
+			data, err := readInput("-", strings.NewReader(`{"type": "string"}`))
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"type": "string"}`)
+		})
+
+		Convey("Then the schema name should fall back to schema", func() {
+			So(inputSchemaName("-"), ShouldEqual, "schema")
+			So(inputSchemaName("dir/user.schema.json"), ShouldEqual, "user")
+		})
+	})
+}
+
+func TestURLInput(t *testing.T) {
+	Convey("Given a server with a schema", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/schemas/user.json" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(`{"type": "string"}`))
+		}))
+		defer server.Close()
+
+		Convey("Then the schema should be fetched from its URL", func() {
+			data, err := readInput(server.URL+"/schemas/user.json", nil)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"type": "string"}`)
+		})
+
+		Convey("Then a missing schema should be an error with the status", func() {
+			_, err := readInput(server.URL+"/schemas/missing.json", nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "404 Not Found")
+		})
+
+		Convey("Then the schema name should come from the last path segment", func() {
+			So(inputSchemaName(server.URL+"/schemas/user.json?v=2"), ShouldEqual, "user")
+		})
+	})
+}
+
+func TestCheckInputs(t *testing.T) {
+	Convey("Given multiple inputs", t, func() {
+		inputs := []string{"user.json", "-", "http://example.com/order.json"}
+
+		Convey("Then they should be accepted with the default flags", func() {
+			So(checkInputs(inputs), ShouldBeNil)
+		})
+
+		Convey("Then flags naming a single output should be rejected", func() {
+			*outputFile = "types.go"
+			defer func() { *outputFile = "" }()
+			So(checkInputs(inputs), ShouldNotBeNil)
+			So(checkInputs(inputs[:1]), ShouldBeNil)
+		})
+
+		Convey("Then a root type name should be rejected", func() {
+			*rootTypeName = "User"
+			defer func() { *rootTypeName = "" }()
+			So(checkInputs(inputs), ShouldNotBeNil)
+		})
+
+		Convey("Then stdin should only be read once", func() {
+			So(checkInputs([]string{"-", "user.json", "-"}), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given --gen-test", t, func() {
+		*genTest = true
+		defer func() { *genTest = false }()
+
+		Convey("Then it should be rejected with --console, since there's no file to test", func() {
+			So(checkInputs([]string{"user.json"}), ShouldBeNil)
+			*outToStdout = true
+			defer func() { *outToStdout = false }()
+			So(checkInputs([]string{"user.json"}), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given --split-files", t, func() {
+		*splitFiles = true
+		defer func() { *splitFiles = false }()
+
+		Convey("Then it should be accepted with --out-dir", func() {
+			*outDir = "types"
+			defer func() { *outDir = "" }()
+			So(checkInputs([]string{"user.json"}), ShouldBeNil)
+		})
+
+		Convey("Then flags for a single output should be rejected", func() {
+			*outputFile = "types.go"
+			defer func() { *outputFile = "" }()
+			So(checkInputs([]string{"user.json"}), ShouldNotBeNil)
+			*outputFile = ""
+			*outToStdout = true
+			defer func() { *outToStdout = false }()
+			So(checkInputs([]string{"user.json"}), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseTypeMappings(t *testing.T) {
+	Convey("Given --type-mappings pairs", t, func() {
+		mappings, err := parseTypeMappings("email=string, decimal=github.com/shopspring/decimal.Decimal,")
+
+		Convey("Then they should map the formats to the types", func() {
+			So(err, ShouldBeNil)
+			So(mappings, ShouldResemble, map[string]string{"email": "string", "decimal": "github.com/shopspring/decimal.Decimal"})
+		})
+	})
+
+	Convey("Given items that aren't pairs", t, func() {
+		Convey("Then they should be rejected", func() {
+			for _, list := range []string{"email", "=string", "email="} {
+				_, err := parseTypeMappings(list)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestYAMLInput(t *testing.T) {
+	Convey("Given a schema written in YAML", t, func() {
+		schemaYAML := `
+type: object
+properties:
+  name: {type: string}
+  age:
+    type: [integer, "null"]
+    minimum: 0
+required: [name]
+`
+
+		Convey("Then it should be converted to JSON, keeping the order of its keys", func() {
+			schemaJSON, err := yamlToJSON([]byte(schemaYAML))
+			So(err, ShouldBeNil)
+			So(string(schemaJSON), ShouldEqual, `{"type":"object","properties":{"name":{"type":"string"},"age":{"type":["integer","null"],"minimum":0}},"required":["name"]}`)
+		})
+
+		Convey("Then a map key that is not a string should be an error with its location", func() {
+			_, err := yamlToJSON([]byte("type: object\nproperties:\n  1: {type: string}\n"))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "#/properties")
+		})
+	})
+}